@@ -0,0 +1,128 @@
+// Аутентификация и авторизация API Rule Engine и сервиса баллов: JWT с ключами из локального JWKS и mTLS между сервисами.
+// Права задаются областями (scopes), роли токена и сертификата раскрываются в набор областей
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Области доступа
+const (
+	SCOPE_RULES_READ      = "rules:read"      // чтение правил
+	SCOPE_RULES_WRITE     = "rules:write"     // изменение правил, включает rules:read
+	SCOPE_RULES_CALCULATE = "rules:calculate" // расчет баллов по заказу
+	SCOPE_POINTS_READ     = "points:read"     // балансы и история транзакций
+	SCOPE_POINTS_WRITE    = "points:write"    // резервирование, списание, перевод, включает points:read
+	SCOPE_POINTS_ADMIN    = "points:admin"    // ручная корректировка, включает points:write
+)
+
+// область, не требующая аутентификации
+const PUBLIC = ""
+
+// области, включенные в область
+var implied = map[string][]string{
+	SCOPE_RULES_WRITE:  {SCOPE_RULES_READ},
+	SCOPE_POINTS_WRITE: {SCOPE_POINTS_READ},
+	SCOPE_POINTS_ADMIN: {SCOPE_POINTS_WRITE, SCOPE_POINTS_READ},
+}
+
+// Роли
+const (
+	ROLE_ADMIN     = "admin"     // все области
+	ROLE_MARKETING = "marketing" // ведение правил начисления
+	ROLE_SUPPORT   = "support"   // поддержка клиентов: балансы, операции и корректировки
+	ROLE_SERVICE   = "service"   // межсервисные вызовы: расчет баллов, операции с баллами
+	ROLE_CLIENT    = "client"    // фронтенд и партнеры: чтение балансов
+)
+
+// Области ролей
+var Roles = map[string][]string{
+	ROLE_ADMIN:     {SCOPE_RULES_WRITE, SCOPE_RULES_CALCULATE, SCOPE_POINTS_ADMIN},
+	ROLE_MARKETING: {SCOPE_RULES_WRITE},
+	ROLE_SUPPORT:   {SCOPE_POINTS_ADMIN},
+	ROLE_SERVICE:   {SCOPE_RULES_CALCULATE, SCOPE_POINTS_WRITE},
+	ROLE_CLIENT:    {SCOPE_POINTS_READ},
+}
+
+// Способы аутентификации
+const (
+	METHOD_JWT  = "jwt"
+	METHOD_MTLS = "mtls"
+)
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("permission denied")
+)
+
+// Аутентифицированный клиент
+type Principal struct {
+	Subject string          // sub токена или CN сертификата
+	Method  string          // jwt, mtls
+	Scopes  map[string]bool // области с учетом ролей и вложенных областей
+}
+
+func NewPrincipal(subject string, method string, scopes []string, roles []string) Principal {
+	p := Principal{Subject: subject, Method: method, Scopes: make(map[string]bool)}
+	for _, role := range roles {
+		for _, scope := range Roles[role] {
+			p.grant(scope)
+		}
+	}
+	for _, scope := range scopes {
+		p.grant(scope)
+	}
+	return p
+}
+
+func (p Principal) grant(scope string) {
+	p.Scopes[scope] = true
+	for _, s := range implied[scope] {
+		p.Scopes[s] = true
+	}
+}
+
+// проверка области, PUBLIC доступна всем
+func (p Principal) Has(scope string) bool {
+	return scope == PUBLIC || p.Scopes[scope]
+}
+
+// Проверка области: ErrForbidden, если у клиента ее нет
+func (p Principal) Require(scope string) error {
+	if !p.Has(scope) {
+		return fmt.Errorf("%w: scope %s is required", ErrForbidden, scope)
+	}
+	return nil
+}
+
+// Проверка доступа к данным пользователя: свои данные доступны всегда, чужие - только с областью scope
+func (p Principal) RequireUser(user string, scope string) error {
+	if p.Subject == user || p.Has(scope) {
+		return nil
+	}
+	return fmt.Errorf("%w: scope %s is required to access user %s", ErrForbidden, scope, user)
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// клиент запроса, ok = false - аутентификация выключена или не выполнялась
+func FromContext(ctx context.Context) (p Principal, ok bool) {
+	p, ok = ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// токен из заголовка "Bearer <token>"
+func bearer(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// JWKS с RSA ключом kid=rsa и EC ключом kid=ec
+func testJWKS(t *testing.T) (*JWKS, *rsa.PrivateKey, *ecdsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	enc := base64.RawURLEncoding.EncodeToString
+	data, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": enc(rsaKey.N.Bytes()), "e": enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": enc(ecKey.X.Bytes()), "y": enc(ecKey.Y.Bytes())},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+	require.NoError(t, err)
+	jwks, err := ParseJWKS(data)
+	require.NoError(t, err)
+	return jwks, rsaKey, ecKey
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func TestPrincipalScopes(t *testing.T) {
+	p := NewPrincipal("u", METHOD_JWT, []string{SCOPE_RULES_WRITE}, []string{ROLE_SUPPORT})
+	// вложенные области и области ролей
+	for _, scope := range []string{SCOPE_RULES_WRITE, SCOPE_RULES_READ, SCOPE_POINTS_ADMIN, SCOPE_POINTS_WRITE, SCOPE_POINTS_READ, PUBLIC} {
+		require.True(t, p.Has(scope), scope)
+	}
+	require.ErrorIs(t, p.Require(SCOPE_RULES_CALCULATE), ErrForbidden)
+
+	client := NewPrincipal("c", METHOD_JWT, nil, []string{ROLE_CLIENT})
+	require.NoError(t, client.Require(SCOPE_POINTS_READ))
+	require.ErrorIs(t, client.Require(SCOPE_POINTS_WRITE), ErrForbidden)
+
+	// клиент читает только свои данные, сервис - любые
+	require.NoError(t, client.RequireUser("c", SCOPE_POINTS_WRITE))
+	require.ErrorIs(t, client.RequireUser("u", SCOPE_POINTS_WRITE), ErrForbidden)
+	service := NewPrincipal("points", METHOD_MTLS, nil, []string{ROLE_SERVICE})
+	require.NoError(t, service.RequireUser("u", SCOPE_POINTS_WRITE))
+}
+
+func TestAuthenticateJWT(t *testing.T) {
+	jwks, rsaKey, ecKey := testJWKS(t)
+	a := &Authenticator{jwks: jwks, issuer: "loyalty", audience: "points"}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	token := sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{
+		"sub": "web", "iss": "loyalty", "aud": "points", "exp": exp, "scope": "points:read rules:read",
+	})
+	p, err := a.Authenticate(token, nil)
+	require.NoError(t, err)
+	require.Equal(t, "web", p.Subject)
+	require.Equal(t, METHOD_JWT, p.Method)
+	require.True(t, p.Has(SCOPE_POINTS_READ))
+	require.False(t, p.Has(SCOPE_POINTS_WRITE))
+
+	token = sign(t, jwt.SigningMethodES256, "ec", ecKey, jwt.MapClaims{
+		"sub": "crm", "iss": "loyalty", "aud": "points", "exp": exp, "roles": []string{ROLE_MARKETING}, "scp": []string{SCOPE_POINTS_READ},
+	})
+	p, err = a.Authenticate(token, nil)
+	require.NoError(t, err)
+	require.True(t, p.Has(SCOPE_RULES_WRITE))
+	require.True(t, p.Has(SCOPE_POINTS_READ))
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"истек", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"iss": "loyalty", "aud": "points", "exp": time.Now().Add(-time.Hour).Unix()})},
+		{"нет exp", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"iss": "loyalty", "aud": "points"})},
+		{"другой iss", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"iss": "other", "aud": "points", "exp": exp})},
+		{"другой aud", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"iss": "loyalty", "aud": "engine", "exp": exp})},
+		{"чужой ключ", sign(t, jwt.SigningMethodRS256, "rsa", otherKey, jwt.MapClaims{"iss": "loyalty", "aud": "points", "exp": exp})},
+		{"неизвестный kid", sign(t, jwt.SigningMethodRS256, "other", rsaKey, jwt.MapClaims{"iss": "loyalty", "aud": "points", "exp": exp})},
+		{"ключ шифрования", sign(t, jwt.SigningMethodRS256, "enc", rsaKey, jwt.MapClaims{"iss": "loyalty", "aud": "points", "exp": exp})},
+		{"HMAC", sign(t, jwt.SigningMethodHS256, "rsa", []byte("secret"), jwt.MapClaims{"iss": "loyalty", "aud": "points", "exp": exp})},
+		{"не JWT", "token"},
+	}
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			_, err := a.Authenticate(ts.token, nil)
+			require.ErrorIs(t, err, ErrUnauthenticated)
+		})
+	}
+}
+
+func testCert(t *testing.T, cn string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestAuthenticateMTLS(t *testing.T) {
+	roles, err := parseCertRoles("points=service,ops=admin|support")
+	require.NoError(t, err)
+	a := &Authenticator{mtls: true, certRoles: roles}
+
+	p, err := a.Authenticate("", [][]*x509.Certificate{{testCert(t, "points")}})
+	require.NoError(t, err)
+	require.Equal(t, METHOD_MTLS, p.Method)
+	require.True(t, p.Has(SCOPE_RULES_CALCULATE))
+	require.False(t, p.Has(SCOPE_RULES_WRITE))
+
+	// сертификат без ролей аутентифицирован, но без прав
+	p, err = a.Authenticate("", [][]*x509.Certificate{{testCert(t, "gateway")}})
+	require.NoError(t, err)
+	require.ErrorIs(t, p.Require(SCOPE_POINTS_READ), ErrForbidden)
+
+	_, err = a.Authenticate("", nil)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+	// JWT не принимаются
+	_, err = a.Authenticate("token", [][]*x509.Certificate{{testCert(t, "points")}})
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = parseCertRoles("points=owner")
+	require.Error(t, err)
+	_, err = parseCertRoles("points")
+	require.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	jwks, rsaKey, _ := testJWKS(t)
+	a := &Authenticator{jwks: jwks, mtls: true, certRoles: map[string][]string{"points": {ROLE_SERVICE}}}
+	scopes := map[string]string{"/rule": SCOPE_RULES_WRITE, "/calculate": SCOPE_RULES_CALCULATE, "/metrics": PUBLIC}
+	handler := Middleware(a, func(r *http.Request) (string, bool) {
+		scope, ok := scopes[r.URL.Path]
+		return scope, ok
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := FromContext(r.Context())
+		w.Write([]byte(p.Subject))
+	}))
+	marketing := sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"sub": "crm", "exp": time.Now().Add(time.Hour).Unix(), "roles": []string{ROLE_MARKETING}})
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		cert  string
+		code  int
+	}{
+		{"без учетных данных", "/rule", "", "", http.StatusUnauthorized},
+		{"публичный маршрут", "/metrics", "", "", http.StatusOK},
+		{"неописанный маршрут", "/all", marketing, "", http.StatusForbidden},
+		{"есть область", "/rule", marketing, "", http.StatusOK},
+		{"нет области", "/calculate", marketing, "", http.StatusForbidden},
+		{"сертификат сервиса", "/calculate", "", "points", http.StatusOK},
+		{"токен важнее сертификата", "/calculate", marketing, "points", http.StatusForbidden},
+	}
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, ts.path, nil)
+			if ts.token != "" {
+				req.Header.Set("Authorization", "Bearer "+ts.token)
+			}
+			if ts.cert != "" {
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{testCert(t, ts.cert)}}}
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, ts.code, rec.Code)
+			if ts.code == http.StatusUnauthorized {
+				require.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	// аутентификация выключена
+	open := Middleware(nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/rule", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequireJWT(t *testing.T) {
+	jwks, rsaKey, _ := testJWKS(t)
+	a := &Authenticator{jwks: jwks, mtls: true, certRoles: map[string][]string{"gateway": {ROLE_SERVICE}}}
+	handler := RequireJWT(a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	client := sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(time.Hour).Unix(), "roles": []string{ROLE_CLIENT}})
+	expired := sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(-time.Hour).Unix()})
+
+	tests := []struct {
+		name  string
+		token string
+		cert  string
+		code  int
+	}{
+		{"без токена", "", "", http.StatusUnauthorized},
+		{"сертификат без токена", "", "gateway", http.StatusUnauthorized},
+		{"просроченный токен", expired, "", http.StatusUnauthorized},
+		{"токен клиента", client, "", http.StatusOK},
+	}
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/u1/balance", nil)
+			if ts.token != "" {
+				req.Header.Set("Authorization", "Bearer "+ts.token)
+			}
+			if ts.cert != "" {
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{testCert(t, ts.cert)}}}
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, ts.code, rec.Code)
+		})
+	}
+}
+
+func TestAuthorizeGRPC(t *testing.T) {
+	jwks, rsaKey, _ := testJWKS(t)
+	a := &Authenticator{jwks: jwks}
+	scopes := map[string]string{"/points.GetPoints/GetBalance": SCOPE_POINTS_READ, "/points.GetPoints/Adjust": SCOPE_POINTS_ADMIN}
+	client := sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"sub": "app", "exp": time.Now().Add(time.Hour).Unix(), "roles": []string{ROLE_CLIENT}})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+client))
+
+	ctx2, err := authorize(ctx, a, scopes, "/points.GetPoints/GetBalance")
+	require.NoError(t, err)
+	p, ok := FromContext(ctx2)
+	require.True(t, ok)
+	require.Equal(t, "app", p.Subject)
+
+	_, err = authorize(ctx, a, scopes, "/points.GetPoints/Adjust")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = authorize(ctx, a, scopes, "/points.GetPoints/Unknown")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = authorize(context.Background(), a, scopes, "/points.GetPoints/GetBalance")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
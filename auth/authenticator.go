@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Проверка учетных данных запроса: JWT и/или клиентский сертификат mTLS
+type Authenticator struct {
+	jwks      *JWKS               // nil - JWT не принимается
+	mtls      bool                // принимаются клиентские сертификаты
+	issuer    string              // ожидаемый iss, пустой - не проверяется
+	audience  string              // ожидаемый aud, пустой - не проверяется
+	certRoles map[string][]string // CN сертификата -> роли
+}
+
+// Настройки из переменных окружения с префиксом сервиса (ENGINE, POINTS):
+//
+//	<prefix>_AUTH            none | jwt | mtls | jwt,mtls
+//	<prefix>_AUTH_JWKS_FILE  файл JWKS с ключами проверки JWT
+//	<prefix>_AUTH_ISSUER     ожидаемый iss токена
+//	<prefix>_AUTH_AUDIENCE   ожидаемый aud токена
+//	<prefix>_AUTH_CERT_ROLES роли клиентских сертификатов: "<CN>=<роль>[|<роль>],..."
+//
+// none - аутентификация выключена, возвращается nil
+func NewAuthenticatorFromEnv(prefix string) (a *Authenticator, err error) {
+	mode := os.Getenv(prefix + "_AUTH")
+	if mode == "" {
+		return nil, fmt.Errorf("env %s_AUTH is not set", prefix)
+	}
+	if mode == "none" {
+		return nil, nil
+	}
+
+	a = &Authenticator{certRoles: make(map[string][]string)}
+	for _, m := range strings.Split(mode, ",") {
+		switch strings.TrimSpace(m) {
+		case METHOD_JWT:
+			path := os.Getenv(prefix + "_AUTH_JWKS_FILE")
+			if path == "" {
+				return nil, fmt.Errorf("env %s_AUTH_JWKS_FILE is not set", prefix)
+			}
+			a.jwks, err = LoadJWKS(path)
+			if err != nil {
+				return nil, err
+			}
+			a.issuer = os.Getenv(prefix + "_AUTH_ISSUER")
+			a.audience = os.Getenv(prefix + "_AUTH_AUDIENCE")
+		case METHOD_MTLS:
+			a.mtls = true
+			a.certRoles, err = parseCertRoles(os.Getenv(prefix + "_AUTH_CERT_ROLES"))
+			if err != nil {
+				return nil, fmt.Errorf("env %s_AUTH_CERT_ROLES: %w", prefix, err)
+			}
+		default:
+			return nil, fmt.Errorf("env %s_AUTH: unknown method %q", prefix, m)
+		}
+	}
+	return a, nil
+}
+
+// "<CN>=<роль>[|<роль>],..."
+func parseCertRoles(s string) (map[string][]string, error) {
+	certRoles := make(map[string][]string)
+	if s == "" {
+		return certRoles, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		cn, roles, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || cn == "" || roles == "" {
+			return nil, fmt.Errorf("invalid item %q", item)
+		}
+		for _, role := range strings.Split(roles, "|") {
+			if _, ok := Roles[role]; !ok {
+				return nil, fmt.Errorf("unknown role %q", role)
+			}
+			certRoles[cn] = append(certRoles[cn], role)
+		}
+	}
+	return certRoles, nil
+}
+
+// Проверка JWT: токен имеет приоритет над сертификатом, чтобы шлюз с собственным сертификатом передавал права пользователя
+// без токена клиент аутентифицируется проверенным при TLS handshake сертификатом (verified - цепочки из tls.ConnectionState)
+func (a *Authenticator) Authenticate(token string, verified [][]*x509.Certificate) (Principal, error) {
+	if token != "" {
+		if a.jwks == nil {
+			return Principal{}, fmt.Errorf("%w: bearer tokens are not accepted", ErrUnauthenticated)
+		}
+		return a.verifyToken(token)
+	}
+	if a.mtls && len(verified) > 0 && len(verified[0]) > 0 {
+		cn := verified[0][0].Subject.CommonName
+		return NewPrincipal(cn, METHOD_MTLS, nil, a.certRoles[cn]), nil
+	}
+	return Principal{}, fmt.Errorf("%w: credentials are required", ErrUnauthenticated)
+}
+
+// Права токена: области из "scope" (через пробел) или "scp" (массив) и роли из "roles"
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+	Roles []string `json:"roles"`
+}
+
+func (a *Authenticator) verifyToken(token string) (Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if a.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		opts = append(opts, jwt.WithAudience(a.audience))
+	}
+	c := &claims{}
+	_, err := jwt.ParseWithClaims(token, c, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := a.jwks.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		return key, nil
+	}, opts...)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	scopes := c.Scp
+	if c.Scope != "" {
+		scopes = append(scopes, strings.Fields(c.Scope)...)
+	}
+	return NewPrincipal(c.Subject, METHOD_JWT, scopes, c.Roles), nil
+}
+
+// клиентские сертификаты принимаются
+func (a *Authenticator) MTLS() bool {
+	return a != nil && a.mtls
+}
+
+// JWT принимаются
+func (a *Authenticator) JWT() bool {
+	return a != nil && a.jwks != nil
+}
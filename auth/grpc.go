@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC interceptor: проверка учетных данных и области метода
+// scopes - область по полному имени метода, методы без области отклоняются; a = nil - аутентификация выключена
+func UnaryServerInterceptor(a *Authenticator, scopes map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if a == nil {
+			return handler(ctx, req)
+		}
+		ctx, err := authorize(ctx, a, scopes, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor(a *Authenticator, scopes map[string]string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if a == nil {
+			return handler(srv, ss)
+		}
+		ctx, err := authorize(ss.Context(), a, scopes, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ss, ctx})
+	}
+}
+
+// поток с контекстом, содержащим клиента
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+
+func authorize(ctx context.Context, a *Authenticator, scopes map[string]string, method string) (context.Context, error) {
+	required, ok := scopes[method]
+	if !ok {
+		return ctx, status.Error(codes.PermissionDenied, ErrForbidden.Error())
+	}
+	if required == PUBLIC {
+		return ctx, nil
+	}
+
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			token = bearer(values[0])
+		}
+	}
+	var verified [][]*x509.Certificate
+	if pr, ok := peer.FromContext(ctx); ok {
+		if info, ok := pr.AuthInfo.(credentials.TLSInfo); ok {
+			verified = info.State.VerifiedChains
+		}
+	}
+	p, err := a.Authenticate(token, verified)
+	if err == nil {
+		err = p.Require(required)
+	}
+	if err != nil {
+		if errors.Is(err, ErrUnauthenticated) {
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return ctx, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return NewContext(ctx, p), nil
+}
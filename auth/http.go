@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// HTTP middleware: проверка учетных данных и области запроса
+// scope - область маршрута, ok = false - маршрут не описан, запрос отклоняется; a = nil - аутентификация выключена
+func Middleware(a *Authenticator, scope func(r *http.Request) (scope string, ok bool)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if a == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			required, ok := scope(r)
+			if !ok {
+				http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+				return
+			}
+			if required == PUBLIC {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var verified [][]*x509.Certificate
+			if r.TLS != nil {
+				verified = r.TLS.VerifiedChains
+			}
+			p, err := a.Authenticate(bearer(r.Header.Get("Authorization")), verified)
+			if err == nil {
+				err = p.Require(required)
+			}
+			if err != nil {
+				httpError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+		})
+	}
+}
+
+// HTTP middleware шлюза к gRPC: запрос без действительного JWT отклоняется
+// шлюз обращается к серверу со своим сертификатом, поэтому права пользователя передаются только токеном,
+// области метода проверяет gRPC сервер; a = nil - аутентификация выключена
+func RequireJWT(a *Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if a == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearer(r.Header.Get("Authorization"))
+			if token == "" {
+				httpError(w, fmt.Errorf("%w: bearer token is required", ErrUnauthenticated))
+				return
+			}
+			p, err := a.Authenticate(token, nil)
+			if err != nil {
+				httpError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+		})
+	}
+}
+
+// ответ на ошибку аутентификации: 401 без учетных данных, 403 без области
+func httpError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrUnauthenticated) {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusForbidden)
+}
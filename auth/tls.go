@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLS сервера из переменных окружения:
+//
+//	<prefix>_TLS_CERT, <prefix>_TLS_KEY - сертификат и ключ сервера
+//	<prefix>_TLS_CLIENT_CA              - CA клиентских сертификатов, обязательна для mTLS
+//
+// без сертификата сервера возвращается nil - соединения без TLS
+// клиентский сертификат обязателен, если аутентификация только по mTLS, и необязателен, если принимаются и JWT
+func ServerTLS(prefix string, a *Authenticator) (*tls.Config, error) {
+	certfile := os.Getenv(prefix + "_TLS_CERT")
+	if certfile == "" {
+		if a.MTLS() {
+			return nil, fmt.Errorf("env %s_TLS_CERT is not set", prefix)
+		}
+		return nil, nil
+	}
+	keyfile := os.Getenv(prefix + "_TLS_KEY")
+	if keyfile == "" {
+		return nil, fmt.Errorf("env %s_TLS_KEY is not set", prefix)
+	}
+	cert, err := tls.LoadX509KeyPair(certfile, keyfile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if !a.MTLS() {
+		return config, nil
+	}
+
+	cafile := os.Getenv(prefix + "_TLS_CLIENT_CA")
+	if cafile == "" {
+		return nil, fmt.Errorf("env %s_TLS_CLIENT_CA is not set", prefix)
+	}
+	config.ClientCAs, err = loadPool(cafile)
+	if err != nil {
+		return nil, err
+	}
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	if a.JWT() {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
+
+// TLS клиента для межсервисных вызовов:
+//
+//	<prefix>_TLS_CA                     - CA сервера, пустой - системные
+//	<prefix>_TLS_CERT, <prefix>_TLS_KEY - клиентский сертификат для mTLS
+//	<prefix>_TLS_SERVER_NAME            - имя в сертификате сервера, если отличается от адреса
+//
+// без CA и сертификата возвращается nil - соединение без TLS
+func ClientTLS(prefix string) (*tls.Config, error) {
+	cafile := os.Getenv(prefix + "_TLS_CA")
+	certfile := os.Getenv(prefix + "_TLS_CERT")
+	if cafile == "" && certfile == "" {
+		return nil, nil
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: os.Getenv(prefix + "_TLS_SERVER_NAME"),
+	}
+	if cafile != "" {
+		pool, err := loadPool(cafile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if certfile != "" {
+		keyfile := os.Getenv(prefix + "_TLS_KEY")
+		if keyfile == "" {
+			return nil, fmt.Errorf("env %s_TLS_KEY is not set", prefix)
+		}
+		cert, err := tls.LoadX509KeyPair(certfile, keyfile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func loadPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificates", path)
+	}
+	return pool, nil
+}
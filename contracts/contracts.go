@@ -0,0 +1,139 @@
+// Контракты сообщений между сервисами: JSON Schema для заказов, расчета, возвратов и списаний.
+// Схемы общие для Rule Engine и сервиса баллов, сообщения проверяются при получении
+package contracts
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Контракты
+const (
+	ORDER     = "order"     // топик orders
+	CALCULATE = "calculate" // POST /calculate
+	RETURN    = "return"    // топик returns
+	REDEEM    = "redeem"    // очередь redeems.v2
+)
+
+//go:embed schemas/*.schema.json
+var files embed.FS
+
+// Сообщение не соответствует контракту
+var ErrInvalid = errors.New("message does not match contract")
+
+// Ошибка проверки сообщения с причинами отклонения
+type ValidationError struct {
+	Contract string
+	Reasons  []string // "<путь в сообщении>: <причина>"
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Contract, ErrInvalid.Error(), strings.Join(e.Reasons, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalid
+}
+
+var (
+	once    sync.Once
+	schemas map[string]*jsonschema.Schema
+	initErr error
+)
+
+// компиляция встроенных схем
+func compile() {
+	compiler := jsonschema.NewCompiler()
+	names := []string{ORDER, CALCULATE, RETURN, REDEEM}
+	for _, name := range names {
+		file := name + ".schema.json"
+		data, err := files.ReadFile("schemas/" + file)
+		if err != nil {
+			initErr = err
+			return
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			initErr = fmt.Errorf("schema %s: %w", file, err)
+			return
+		}
+		err = compiler.AddResource(file, doc)
+		if err != nil {
+			initErr = err
+			return
+		}
+	}
+	schemas = make(map[string]*jsonschema.Schema, len(names))
+	for _, name := range names {
+		schema, err := compiler.Compile(name + ".schema.json")
+		if err != nil {
+			initErr = err
+			return
+		}
+		schemas[name] = schema
+	}
+}
+
+// Схема контракта в JSON
+func Schema(contract string) ([]byte, error) {
+	return files.ReadFile("schemas/" + contract + ".schema.json")
+}
+
+// Проверка сообщения по контракту, при несоответствии возвращает *ValidationError
+func Validate(contract string, message []byte) error {
+	once.Do(compile)
+	if initErr != nil {
+		return initErr
+	}
+	schema, ok := schemas[contract]
+	if !ok {
+		return fmt.Errorf("unknown contract %s", contract)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(message))
+	if err != nil {
+		return &ValidationError{contract, []string{"invalid JSON: " + err.Error()}}
+	}
+	err = schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	return &ValidationError{contract, reasons(verr.DetailedOutput())}
+}
+
+// причины отклонения - листовые ошибки проверки
+// дерево DetailedOutput, а не BasicOutput: в плоском списке ошибки внутри $ref теряют причину
+func reasons(output *jsonschema.OutputUnit) []string {
+	var result []string
+	var walk func(unit *jsonschema.OutputUnit)
+	walk = func(unit *jsonschema.OutputUnit) {
+		if len(unit.Errors) > 0 {
+			for i := range unit.Errors {
+				walk(&unit.Errors[i])
+			}
+			return
+		}
+		if unit.Error == nil {
+			return
+		}
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		result = append(result, location+": "+unit.Error.String())
+	}
+	walk(output)
+	sort.Strings(result)
+	return result
+}
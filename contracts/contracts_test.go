@@ -0,0 +1,66 @@
+package contracts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		contract string
+		msg      string
+		valid    bool
+		reason   string
+	}{
+		{"заказ", ORDER, `{"orderId":"o1","userId":"u1","total":100.5,"city":"Moscow","items":[{"price":10,"sku":"a"}]}`, true, ""},
+		{"заказ без total", ORDER, `{"orderId":"o1","userId":"u1"}`, false, "total"},
+		{"суммы строкой", ORDER, `{"orderId":"o1","userId":"u1","total":"100.50","items":[{"price":"10"}]}`, true, ""},
+		{"total не число", ORDER, `{"orderId":"o1","userId":"u1","total":"abc"}`, false, "/total"},
+		{"отрицательная цена строкой", ORDER, `{"orderId":"o1","userId":"u1","total":1,"items":[{"price":"-1"}]}`, false, "/items/0/price"},
+		{"отрицательный total", ORDER, `{"orderId":"o1","userId":"u1","total":-1}`, false, "/total"},
+		{"позиция без цены", ORDER, `{"orderId":"o1","userId":"u1","total":1,"items":[{"sku":"a"}]}`, false, "/items/0"},
+		{"невалидный JSON", ORDER, `{"orderId":`, false, "invalid JSON"},
+		{"заказ без пользователя", ORDER, `{"orderId":"o1","total":1,"items":[{"price":1}]}`, false, "userId"},
+		{"заказ без позиций", ORDER, `{"orderId":"o1","userId":"u1","total":1}`, false, "items"},
+		{"расчет корзины", CALCULATE, `{"total":100.5,"city":"Moscow","items":[{"price":10,"sku":"a"}]}`, true, ""},
+		{"расчет без позиций", CALCULATE, `{"total":100.5}`, false, "items"},
+		{"расчет без total", CALCULATE, `{"items":[{"price":10}]}`, false, "total"},
+		{"возврат", RETURN, `{"orderId":"o1","userId":"u1"}`, true, ""},
+		{"возврат без заказа", RETURN, `{"userId":"u1"}`, false, "orderId"},
+		{"списание", REDEEM, `{"userId":"u1","points":10,"redeemId":"r1","items":[{"sku":"s1","price":100}]}`, true, ""},
+		{"списание промо-баллов", REDEEM, `{"userId":"u1","wallet":"PROMO","points":10,"redeemId":"r1","items":[{"sku":"s1","price":100}]}`, true, ""},
+		{"неизвестный кошелек", REDEEM, `{"userId":"u1","wallet":"GOLD","points":10,"redeemId":"r1"}`, false, "/wallet"},
+		{"резерв", REDEEM, `{"type":"hold","userId":"u1","points":10,"redeemId":"r1","ttl":60,"items":[{"sku":"s1","price":100}]}`, true, ""},
+		{"списание без корзины", REDEEM, `{"userId":"u1","points":10,"redeemId":"r1"}`, true, ""},
+		{"подтверждение резерва", REDEEM, `{"type":"capture","redeemId":"r1"}`, true, ""},
+		{"списание без баллов", REDEEM, `{"userId":"u1","redeemId":"r1"}`, false, "points"},
+		{"отрицательные баллы", REDEEM, `{"userId":"u1","points":-1,"redeemId":"r1"}`, false, "/points"},
+		{"неизвестный тип", REDEEM, `{"type":"refund","redeemId":"r1"}`, false, "/type"},
+		{"дополнительное поле", REDEEM, `{"redeemId":"r1","type":"release","source":"pos"}`, true, ""},
+		{"лишнее поле позиции", REDEEM, `{"userId":"u1","points":10,"redeemId":"r1","items":[{"price":100,"amount":1}]}`, false, "amount"},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			err := Validate(ts.contract, []byte(ts.msg))
+			if ts.valid {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrInvalid)
+			var verr *ValidationError
+			require.True(t, errors.As(err, &verr))
+			require.NotEmpty(t, verr.Reasons)
+			require.Contains(t, err.Error(), ts.reason)
+		})
+	}
+}
+
+func TestValidateUnknownContract(t *testing.T) {
+	err := Validate("payment", []byte(`{}`))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrInvalid)
+}
@@ -8,10 +8,13 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/glkeru/loyalty/auth"
 	api "github.com/glkeru/loyalty/engine/internal/api"
 	db "github.com/glkeru/loyalty/engine/internal/db"
 	engine "github.com/glkeru/loyalty/engine/internal/interfaces"
+	rates "github.com/glkeru/loyalty/engine/internal/rates"
 	trace "github.com/glkeru/loyalty/engine/observability/otel"
+	"github.com/glkeru/loyalty/health"
 	"go.uber.org/zap"
 )
 
@@ -37,19 +40,54 @@ func main() {
 	}
 	storage = dt
 
+	// курсы валют: ENGINE_RATES_FILE не задан - заказы и правила рассчитываются только без указания валюты,
+	// задан, но не читается - запуск прерывается, иначе все заказы с валютой отклонялись бы
+	var rateProvider engine.RateProvider
+	if os.Getenv("ENGINE_RATES_FILE") == "" {
+		logger.Warn("env ENGINE_RATES_FILE is not set, exchange rates are disabled")
+	} else {
+		rt, err := rates.NewStaticRatesFromEnv()
+		if err != nil {
+			panic(err)
+		}
+		rateProvider = rt
+	}
+
+	// аутентификация: JWT и/или mTLS
+	authn, err := auth.NewAuthenticatorFromEnv("ENGINE")
+	if err != nil {
+		panic(err)
+	}
+	tlsConfig, err := auth.ServerTLS("ENGINE", authn)
+	if err != nil {
+		panic(err)
+	}
+
 	traceShutdown := trace.InitTracer(context.Background())
 	defer traceShutdown()
 
+	// проверки состояния: /healthz, /readyz
+	checker := health.New()
+	checker.Add("mongo", dt.Ping)
+
 	// server
-	r := api.NewHandler(storage, logger)
+	r := api.NewHandler(storage, rateProvider, authn, checker, logger)
 	srv := &http.Server{
 		Handler:      r,
 		Addr:         ":" + port,
 		WriteTimeout: 10 * time.Second,
 		ReadTimeout:  10 * time.Second,
+		TLSConfig:    tlsConfig,
 	}
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			// сертификаты заданы в TLSConfig
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			panic(err)
 		}
 	}()
@@ -58,6 +96,9 @@ func main() {
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 	<-interrupt
+	// сервис не готов: балансировщик перестает направлять запросы, текущие запросы завершаются
+	checker.Shutdown()
+	time.Sleep(health.DrainDelay("ENGINE"))
 	timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	err = srv.Shutdown(timeout)
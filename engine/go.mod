@@ -5,6 +5,10 @@ go 1.24.2
 replace github.com/glkeru/loyalty/engine => ./engine
 
 require (
+	github.com/glkeru/loyalty/auth v0.0.0
+	github.com/glkeru/loyalty/contracts v0.0.0
+	github.com/glkeru/loyalty/health v0.0.0
+	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
 	github.com/prometheus/client_golang v1.23.2
@@ -37,6 +41,7 @@ require (
 	github.com/prometheus/client_model v0.6.2 // indirect
 	github.com/prometheus/common v0.66.1 // indirect
 	github.com/prometheus/procfs v0.16.1 // indirect
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2 // indirect
 	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
 	github.com/xdg-go/scram v1.1.2 // indirect
 	github.com/xdg-go/stringprep v1.0.4 // indirect
@@ -57,3 +62,9 @@ require (
 	google.golang.org/protobuf v1.36.8 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/glkeru/loyalty/contracts => ../contracts
+
+replace github.com/glkeru/loyalty/auth => ../auth
+
+replace github.com/glkeru/loyalty/health => ../health
@@ -1,13 +1,18 @@
 package engine
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
+	"github.com/glkeru/loyalty/auth"
+	contracts "github.com/glkeru/loyalty/contracts"
 	engine "github.com/glkeru/loyalty/engine/internal/interfaces"
 	models "github.com/glkeru/loyalty/engine/internal/models"
 	service "github.com/glkeru/loyalty/engine/internal/services"
+	"github.com/glkeru/loyalty/health"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -18,19 +23,27 @@ import (
 type RulesHandler struct {
 	router *mux.Router
 	db     engine.RuleStorage
+	rates  engine.RateProvider
 	logger *zap.Logger
 }
 
 type CalculateResponse struct {
-	Points int32 `json:"points"`
+	Points  int32            `json:"points"`  // баллы по всем кошелькам
+	Wallets map[string]int32 `json:"wallets"` // баллы по кошелькам начисления
 }
 
-func NewHandler(db engine.RuleStorage, logger *zap.Logger) *RulesHandler {
+// authn = nil - аутентификация выключена, checker = nil - проверки зависимостей не заданы
+func NewHandler(db engine.RuleStorage, rates engine.RateProvider, authn *auth.Authenticator, checker *health.Checker, logger *zap.Logger) *RulesHandler {
 
 	router := mux.NewRouter()
-	handler := &RulesHandler{router, db, logger}
+	handler := &RulesHandler{router, db, rates, logger}
 
 	router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	if checker == nil {
+		checker = health.New()
+	}
+	router.Handle("/healthz", checker.LivenessHandler()).Methods(http.MethodGet)
+	router.Handle("/readyz", checker.ReadinessHandler()).Methods(http.MethodGet)
 
 	router.Handle("/calculate", otelhttp.NewHandler(http.HandlerFunc(handler.CalculateHandler), "calculate")).Methods(http.MethodPost)
 	router.Handle("/rules", otelhttp.NewHandler(http.HandlerFunc(handler.GetActiveRulesHandler), "rules")).Methods(http.MethodGet)
@@ -39,6 +52,7 @@ func NewHandler(db engine.RuleStorage, logger *zap.Logger) *RulesHandler {
 	router.Handle("/rule", otelhttp.NewHandler(http.HandlerFunc(handler.SaveRuleHandler), "ruleUpsert")).Methods(http.MethodPost)
 
 	router.Use(MiddlewareLog())
+	router.Use(MiddlewareAuth(authn))
 
 	return handler
 }
@@ -56,7 +70,7 @@ func (r *RulesHandler) Log(msg string, service string, err error) {
 
 // Расчет баллов
 func (r RulesHandler) CalculateHandler(w http.ResponseWriter, req *http.Request) {
-	serv, err := service.NewRuleEngineService(r.db, r.logger)
+	serv, err := service.NewRuleEngineService(r.db, r.rates, r.logger)
 	if err != nil {
 		r.Log("Service init", "CalculateHandler", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -72,7 +86,21 @@ func (r RulesHandler) CalculateHandler(w http.ResponseWriter, req *http.Request)
 		return
 	}
 	defer req.Body.Close()
-	err = json.Unmarshal(body, &order)
+	// проверка контракта заказа, причины отклонения возвращаются в ответе
+	err = contracts.Validate(contracts.CALCULATE, body)
+	if err != nil {
+		r.Log("Validate", "CalculateHandler", err)
+		if errors.Is(err, contracts.ErrInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	// числа читаются как json.Number без потери точности
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err = decoder.Decode(&order)
 	if err != nil {
 		r.Log("Unmarshal", "CalculateHandler", err)
 		http.Error(w, "Body is not correct", http.StatusBadRequest)
@@ -80,8 +108,20 @@ func (r RulesHandler) CalculateHandler(w http.ResponseWriter, req *http.Request)
 	}
 
 	// расчет
-	points := serv.Calculate(req.Context(), order)
-	response := &CalculateResponse{points}
+	wallets, err := serv.CalculateWallets(req.Context(), order)
+	if err != nil {
+		r.Log("Calculate", "CalculateHandler", err)
+		if errors.Is(err, models.ErrMalformedOrder) || errors.Is(err, models.ErrUnknownCurrency) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	response := &CalculateResponse{0, wallets}
+	for _, p := range wallets {
+		response.Points += p
+	}
 
 	// формирование ответа
 	j, err := json.Marshal(response)
@@ -180,6 +220,10 @@ func (r RulesHandler) SaveRuleHandler(w http.ResponseWriter, req *http.Request)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if rule.Wallet != "" && !models.Wallets[rule.Wallet] {
+		http.Error(w, "Unknown wallet "+rule.Wallet, http.StatusBadRequest)
+		return
+	}
 	err = r.db.SaveRule(req.Context(), *rule)
 	if err != nil {
 		r.Log("SaveRule", "SaveRuleHandler", err)
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	engine "github.com/glkeru/loyalty/engine/internal/interfaces"
+	models "github.com/glkeru/loyalty/engine/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// хранилище с одним правилом: 10% от заказа на сумму от 100
+type rulesStorage struct {
+	engine.RuleStorage
+}
+
+func (s rulesStorage) GetActiveRules(ctx context.Context) ([]models.Rule, error) {
+	return []models.Rule{{
+		ID:     uuid.New(),
+		Active: true,
+		Header: models.RewardCriteria{
+			Include: []models.Criteria{{
+				Operator:   "AND",
+				Conditions: []models.Condition{{Field: "total", Operator: ">=", Value: 100}},
+			}},
+			Percent: 10,
+		},
+	}}, nil
+}
+
+func TestCalculateHandler(t *testing.T) {
+	handler := NewHandler(rulesStorage{}, nil, nil, nil, zap.NewNop())
+
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		points int32
+	}{
+		{"сумма числом", `{"orderId":"o1","userId":"u1","total":100.50,"items":[{"price":100.50}]}`, http.StatusOK, 11},
+		{"сумма строкой", `{"orderId":"o1","userId":"u1","total":"100.50","items":[{"price":"100.50"}]}`, http.StatusOK, 11},
+		{"корзина без заказа", `{"total":100.50,"items":[{"price":100.50}]}`, http.StatusOK, 11},
+		{"сумма не число", `{"orderId":"o1","userId":"u1","total":"abc","items":[]}`, http.StatusBadRequest, 0},
+		{"без позиций", `{"total":100.50}`, http.StatusBadRequest, 0},
+	}
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/calculate", strings.NewReader(ts.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, ts.code, rec.Code, rec.Body.String())
+			if ts.code != http.StatusOK {
+				return
+			}
+			var resp CalculateResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Equal(t, ts.points, resp.Points)
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/glkeru/loyalty/auth"
+	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -70,3 +72,31 @@ func MiddlewareLog() func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// области доступа маршрутов: "<метод> <шаблон пути>"
+var routeScopes = map[string]string{
+	"GET /metrics":    auth.PUBLIC,
+	"GET /healthz":    auth.PUBLIC,
+	"GET /readyz":     auth.PUBLIC,
+	"POST /calculate": auth.SCOPE_RULES_CALCULATE,
+	"GET /rules":      auth.SCOPE_RULES_READ,
+	"GET /rule/{id}":  auth.SCOPE_RULES_READ,
+	"GET /all":        auth.SCOPE_RULES_READ,
+	"POST /rule":      auth.SCOPE_RULES_WRITE,
+}
+
+// проверяем JWT/клиентский сертификат и область маршрута, a = nil - аутентификация выключена
+func MiddlewareAuth(a *auth.Authenticator) func(http.Handler) http.Handler {
+	return auth.Middleware(a, func(r *http.Request) (string, bool) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			return "", false
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return "", false
+		}
+		scope, ok := routeScopes[r.Method+" "+path]
+		return scope, ok
+	})
+}
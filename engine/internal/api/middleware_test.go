@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glkeru/loyalty/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// у каждого маршрута есть область доступа, иначе запросы к нему отклоняются
+func TestRouteScopes(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil, zap.NewNop())
+	err := handler.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		require.NoError(t, err)
+		methods, err := route.GetMethods()
+		require.NoError(t, err)
+		for _, method := range methods {
+			_, ok := routeScopes[method+" "+path]
+			require.True(t, ok, method+" "+path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestMiddlewareAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	enc := base64.RawURLEncoding.EncodeToString
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "k1", "n": enc(key.N.Bytes()), "e": enc(big.NewInt(int64(key.E)).Bytes())},
+	}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks, 0600))
+	t.Setenv("ENGINE_AUTH", "jwt")
+	t.Setenv("ENGINE_AUTH_JWKS_FILE", path)
+	authn, err := auth.NewAuthenticatorFromEnv("ENGINE")
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": "reader", "exp": time.Now().Add(time.Hour).Unix(), "scope": auth.SCOPE_RULES_READ,
+	})
+	token.Header["kid"] = "k1"
+	reader, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	handler := NewHandler(nil, nil, authn, nil, zap.NewNop())
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		code   int
+	}{
+		{"метрики без токена", http.MethodGet, "/metrics", "", http.StatusOK},
+		{"готовность без токена", http.MethodGet, "/readyz", "", http.StatusOK},
+		{"изменение правила без токена", http.MethodPost, "/rule", "", http.StatusUnauthorized},
+		{"изменение правила без области", http.MethodPost, "/rule", reader, http.StatusForbidden},
+		{"расчет без области", http.MethodPost, "/calculate", reader, http.StatusForbidden},
+	}
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			req := httptest.NewRequest(ts.method, ts.path, nil)
+			if ts.token != "" {
+				req.Header.Set("Authorization", "Bearer "+ts.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, ts.code, rec.Code)
+		})
+	}
+}
@@ -42,6 +42,11 @@ func NewRulesDB() (*RulesDB, error) {
 	return &RulesDB{client, coll}, nil
 }
 
+// проверка соединения с MongoDB
+func (r RulesDB) Ping(ctx context.Context) error {
+	return r.mgo.Ping(ctx, nil)
+}
+
 // получение активных правил
 func (r RulesDB) GetActiveRules(ctx context.Context) ([]engine.Rule, error) {
 	var rules []engine.Rule
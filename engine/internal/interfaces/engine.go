@@ -19,3 +19,11 @@ type RuleStorage interface {
 	SaveRule(ctx context.Context, rule engine.Rule) error
 	GetRule(ctx context.Context, ruleId uuid.UUID) (rule engine.Rule)
 }
+
+// Курсы валют
+type RateProvider interface {
+	// курс пересчета: amount в from * rate = сумма в to
+	Rate(ctx context.Context, from string, to string) (rate float64, err error)
+	// базовая валюта: валюта заказов и правил, для которых валюта не указана
+	Base() string
+}
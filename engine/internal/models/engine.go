@@ -1,16 +1,22 @@
 package engine
 
-import "github.com/google/uuid"
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
 
 //import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Rule struct {
-	Active  bool             `bson:"active" json:"active" `
-	Maximum bool             `bson:"maximum" json:"maximum"`
-	ID      uuid.UUID        `bson:"id" json:"id"`
-	Name    string           `bson:"name" json:"name"`
-	Header  RewardCriteria   `bson:"header" json:"header"`
-	Items   []RewardCriteria `bson:"items" json:"items"`
+	Active   bool             `bson:"active" json:"active" `
+	Maximum  bool             `bson:"maximum" json:"maximum"`
+	ID       uuid.UUID        `bson:"id" json:"id"`
+	Name     string           `bson:"name" json:"name"`
+	Currency string           `bson:"currency" json:"currency,omitempty"` // валюта порогов и процентов, по умолчанию базовая
+	Wallet   string           `bson:"wallet" json:"wallet,omitempty"`     // кошелек начисления баллов, по умолчанию BASE
+	Header   RewardCriteria   `bson:"header" json:"header"`
+	Items    []RewardCriteria `bson:"items" json:"items"`
 }
 
 type Criteria struct {
@@ -30,3 +36,33 @@ type Condition struct {
 	Operator string `bson:"operator" json:"operator"`
 	Value    any    `bson:"value" json:"value"`
 }
+
+// Заказ не может быть рассчитан: нет обязательного поля или неверный тип значения
+var ErrMalformedOrder = errors.New("malformed order")
+
+// Нет курса для валюты заказа или правила
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Денежные поля заказа и позиций, пересчитываются в валюту правила
+var MonetaryFields = []string{"total", "price"}
+
+// Кошельки счета: основные баллы, промо-баллы со сроком действия, мили партнеров
+const (
+	WALLET_BASE  = "BASE"
+	WALLET_PROMO = "PROMO"
+	WALLET_MILES = "MILES"
+)
+
+var Wallets = map[string]bool{
+	WALLET_BASE:  true,
+	WALLET_PROMO: true,
+	WALLET_MILES: true,
+}
+
+// кошелек, в который начисляются баллы правила
+func (r Rule) WalletCode() string {
+	if r.Wallet == "" {
+		return WALLET_BASE
+	}
+	return r.Wallet
+}
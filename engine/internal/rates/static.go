@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	models "github.com/glkeru/loyalty/engine/internal/models"
+)
+
+// Курсы валют из статического файла
+type StaticRates struct {
+	base  string
+	rates map[string]float64 // стоимость единицы валюты в базовой валюте
+}
+
+// формат файла курсов
+type ratesFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func NewStaticRates(base string, rates map[string]float64) (*StaticRates, error) {
+	base = strings.ToUpper(base)
+	normalized := make(map[string]float64, len(rates)+1)
+	for currency, rate := range rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate for %s must be positive", currency)
+		}
+		normalized[strings.ToUpper(currency)] = rate
+	}
+	if base == "" {
+		return nil, fmt.Errorf("base currency is not set")
+	}
+	normalized[base] = 1
+	return &StaticRates{base, normalized}, nil
+}
+
+// Загрузка курсов из JSON файла: {"base": "RUB", "rates": {"KZT": 0.18, "BYN": 28.5}}
+func LoadStaticRates(path string) (*StaticRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := ratesFile{}
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("rates file %s: %w", path, err)
+	}
+	return NewStaticRates(file.Base, file.Rates)
+}
+
+// Курсы из файла ENGINE_RATES_FILE
+func NewStaticRatesFromEnv() (*StaticRates, error) {
+	path := os.Getenv("ENGINE_RATES_FILE")
+	if path == "" {
+		return nil, fmt.Errorf("env ENGINE_RATES_FILE is not set")
+	}
+	return LoadStaticRates(path)
+}
+
+func (s *StaticRates) Base() string {
+	return s.base
+}
+
+func (s *StaticRates) Rate(ctx context.Context, from string, to string) (float64, error) {
+	fromRate, ok := s.rates[strings.ToUpper(from)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", models.ErrUnknownCurrency, from)
+	}
+	toRate, ok := s.rates[strings.ToUpper(to)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", models.ErrUnknownCurrency, to)
+	}
+	return fromRate / toRate, nil
+}
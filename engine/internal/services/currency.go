@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	models "github.com/glkeru/loyalty/engine/internal/models"
+)
+
+// код валюты ISO 4217
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// базовая валюта: валюта заказов и правил, для которых валюта не указана
+func (s *RuleEngineService) baseCurrency() string {
+	if s.rates == nil {
+		return ""
+	}
+	return s.rates.Base()
+}
+
+// валюта правила
+func (s *RuleEngineService) ruleCurrency(rule models.Rule) string {
+	if rule.Currency == "" {
+		return s.baseCurrency()
+	}
+	return strings.ToUpper(rule.Currency)
+}
+
+// валюта заказа, поле currency необязательное
+func (s *RuleEngineService) orderCurrency(order map[string]any) (string, error) {
+	v, ok := order["currency"]
+	if !ok || v == nil {
+		return s.baseCurrency(), nil
+	}
+	currency, ok := v.(string)
+	if !ok || !currencyCode.MatchString(strings.ToUpper(currency)) {
+		return "", fmt.Errorf("%w: field currency is not a currency code: %v", models.ErrMalformedOrder, v)
+	}
+	return strings.ToUpper(currency), nil
+}
+
+// Заказ в валюте каждого правила: денежные поля пересчитываются по курсу
+// Неизвестная валюта заказа - ошибка models.ErrUnknownCurrency, правила с неизвестной валютой пропускаются
+func (s *RuleEngineService) ordersByCurrency(ctx context.Context, order map[string]any) (map[string]map[string]any, error) {
+	from, err := s.orderCurrency(order)
+	if err != nil {
+		return nil, err
+	}
+	base := s.baseCurrency()
+	if from != base {
+		if s.rates == nil {
+			return nil, fmt.Errorf("%w: %s, exchange rates are not configured", models.ErrUnknownCurrency, from)
+		}
+		if _, err := s.rates.Rate(ctx, from, base); err != nil {
+			return nil, err
+		}
+	}
+
+	orders := make(map[string]map[string]any)
+	failed := make(map[string]bool)
+	for _, rule := range s.Rules {
+		to := s.ruleCurrency(rule)
+		if _, ok := orders[to]; ok || failed[to] {
+			continue
+		}
+		if to == from {
+			orders[to] = order
+			continue
+		}
+		if s.rates == nil {
+			failed[to] = true
+			s.Log(fmt.Errorf("incorrect rule: %s, %w: %s, exchange rates are not configured", rule.ID.String(), models.ErrUnknownCurrency, to))
+			continue
+		}
+		rate, err := s.rates.Rate(ctx, from, to)
+		if err != nil {
+			failed[to] = true
+			s.Log(fmt.Errorf("incorrect rule: %s, %w", rule.ID.String(), err))
+			continue
+		}
+		orders[to] = convertOrder(order, to, rate)
+	}
+	return orders, nil
+}
+
+// заказ для правила в валюте правила
+func (s *RuleEngineService) orderFor(orders map[string]map[string]any, rule models.Rule) (map[string]any, bool) {
+	order, ok := orders[s.ruleCurrency(rule)]
+	return order, ok
+}
+
+// копия заказа с денежными полями заказа и позиций, умноженными на rate
+func convertOrder(order map[string]any, currency string, rate float64) map[string]any {
+	converted := convertFields(order, rate)
+	converted["currency"] = currency
+	if items, err := orderItems(order); err == nil && items != nil {
+		list := make([]any, 0, len(items))
+		for _, i := range items {
+			list = append(list, convertFields(i, rate))
+		}
+		converted["items"] = list
+	}
+	return converted
+}
+
+func convertFields(data map[string]any, rate float64) map[string]any {
+	converted := make(map[string]any, len(data))
+	for k, v := range data {
+		converted[k] = v
+	}
+	for _, field := range models.MonetaryFields {
+		if value, ok := toFloat64(data[field]); ok {
+			converted[field] = value * rate
+		}
+	}
+	return converted
+}
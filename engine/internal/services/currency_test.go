@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/glkeru/loyalty/engine/internal/models"
+	rates "github.com/glkeru/loyalty/engine/internal/rates"
+	uuid "github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// правило: баллы или процент от total, если total >= threshold в валюте правила
+func currencyRule(currency string, threshold float64, points int32, percent int32) models.Rule {
+	return models.Rule{
+		ID:       uuid.New(),
+		Active:   true,
+		Name:     "rule " + currency,
+		Currency: currency,
+		Header: models.RewardCriteria{
+			Points:  points,
+			Percent: percent,
+			Include: []models.Criteria{
+				{
+					Operator: "AND",
+					Conditions: []models.Condition{
+						{Field: "total", Operator: ">=", Value: threshold},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCalculateCurrency(t *testing.T) {
+	// 1 KZT = 0.25 RUB
+	rt, err := rates.NewStaticRates("RUB", map[string]float64{"KZT": 0.25})
+	require.NoError(t, err)
+
+	rules := []models.Rule{
+		currencyRule("", 1000, 0, 10),      // базовая валюта: 10% от заказа от 1000 RUB
+		currencyRule("KZT", 50000, 100, 0), // 100 баллов за заказ от 50000 KZT
+		currencyRule("EUR", 0, 1000, 0),    // нет курса - правило пропускается
+	}
+
+	tests := []TestCase{
+		{Expected: 200, Name: "RUB ниже порога KZT", Data: map[string]any{"total": 2000, "currency": "RUB"}},
+		{Expected: 2100, Name: "RUB выше порога KZT", Data: map[string]any{"total": 20000, "currency": "RUB"}},
+		{Expected: 2100, Name: "KZT пересчитывается в RUB", Data: map[string]any{"total": 80000, "currency": "kzt"}},
+		{Expected: 2100, Name: "без валюты - базовая", Data: map[string]any{"total": 20000}},
+		{
+			Expected: 2100,
+			Name:     "позиции пересчитываются",
+			Data: map[string]any{
+				"total":    80000,
+				"currency": "KZT",
+				"items":    []any{map[string]any{"productid": "a", "price": 80000}},
+			},
+		},
+	}
+
+	for _, mode := range []string{EVAL_CONCURRENT, EVAL_POOL, EVAL_SEQUENTIAL} {
+		serv := &RuleEngineService{Rules: rules, Mode: mode, Workers: 2, rates: rt, logger: zap.NewNop()}
+		for _, ts := range tests {
+			t.Run(mode+"/"+ts.Name, func(t *testing.T) {
+				result, err := serv.Calculate(context.Background(), ts.Data)
+				require.NoError(t, err)
+				require.Equal(t, ts.Expected, result)
+			})
+		}
+
+		_, err := serv.Calculate(context.Background(), map[string]any{"total": 100, "currency": "USD"})
+		require.ErrorIs(t, err, models.ErrUnknownCurrency)
+		_, err = serv.Calculate(context.Background(), map[string]any{"total": 100, "currency": 643})
+		require.ErrorIs(t, err, models.ErrMalformedOrder)
+	}
+
+	// без курсов заказ в валюте не рассчитывается
+	serv := &RuleEngineService{Rules: rules[:1], Mode: EVAL_SEQUENTIAL, logger: zap.NewNop()}
+	_, err = serv.Calculate(context.Background(), map[string]any{"total": 100, "currency": "KZT"})
+	require.ErrorIs(t, err, models.ErrUnknownCurrency)
+	points, err := serv.Calculate(context.Background(), map[string]any{"total": 1000})
+	require.NoError(t, err)
+	require.Equal(t, int32(100), points)
+}
@@ -2,8 +2,12 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,16 +19,21 @@ import (
 )
 
 type RuleEngineService struct {
-	Rules  []models.Rule
-	logger *zap.Logger
+	Rules   []models.Rule
+	Mode    string // режим расчета: concurrent, pool, sequential
+	Workers int    // размер пула для режима pool
+	rates   engine.RateProvider
+	logger  *zap.Logger
 }
 
-func NewRuleEngineService(db engine.RuleStorage, logger *zap.Logger) (service *RuleEngineService, err error) {
+// rates - курсы валют, nil - пересчет валют не поддерживается
+func NewRuleEngineService(db engine.RuleStorage, rates engine.RateProvider, logger *zap.Logger) (service *RuleEngineService, err error) {
 	rules, err := db.GetActiveRules(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	return &RuleEngineService{rules, logger}, nil
+	mode, workers := evalConfig()
+	return &RuleEngineService{rules, mode, workers, rates, logger}, nil
 }
 
 // log
@@ -36,49 +45,134 @@ func (s *RuleEngineService) Log(err error) {
 }
 
 // Расчет баллов по правилам
-func (s *RuleEngineService) Calculate(ctx context.Context, order map[string]any) (points int32) {
-	wg := &sync.WaitGroup{}
-	count := len(s.Rules)
-	wg.Add(count)
+// Некорректный заказ - ошибка models.ErrMalformedOrder, ошибки отдельных правил журналируются, правило пропускается
+func (s *RuleEngineService) Calculate(ctx context.Context, order map[string]any) (points int32, err error) {
+	wallets, err := s.CalculateWallets(ctx, order)
+	if err != nil {
+		return 0, err
+	}
+	return totalPoints(wallets), nil
+}
+
+// Расчет баллов по правилам с разбивкой по кошелькам начисления
+func (s *RuleEngineService) CalculateWallets(ctx context.Context, order map[string]any) (wallets map[string]int32, err error) {
+	err = ValidateOrder(order)
+	if err != nil {
+		return nil, err
+	}
+	// заказ в валютах правил
+	orders, err := s.ordersByCurrency(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s.Mode {
+	case EVAL_SEQUENTIAL:
+		return s.calculateSequential(ctx, orders), nil
+	case EVAL_POOL:
+		return s.calculatePool(ctx, orders), nil
+	}
 
-	var pointsAll int32              // сумма баллов по обычным правилам
-	var pointsMax int32              // наибольшее кол-во баллов среди правил с типом Maximum
-	maxCh := make(chan int32, count) // канал для правил с типом Maximum
+	wg := &sync.WaitGroup{}
+	wg.Add(len(s.Rules))
+	result := newWalletPoints()
 
 	for _, rule := range s.Rules {
 		go func(rule models.Rule) {
 			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					s.Log(fmt.Errorf("incorrect rule: %s, panic: %v", rule.ID.String(), r))
+				}
+			}()
 			select {
 			case <-ctx.Done():
 				return
 			default:
+				order, ok := s.orderFor(orders, rule)
+				if !ok {
+					return
+				}
 				p, err := Relevant(ctx, order, rule)
 				if err != nil {
 					s.Log(err)
 					return
 				}
-				switch rule.Maximum {
-				case true:
-					maxCh <- p
-				default:
-					atomic.AddInt32(&pointsAll, p)
-				}
+				result.add(rule, p)
 			}
 		}(rule)
 	}
 	wg.Wait()
-	close(maxCh)
-	for v := range maxCh {
-		if v > pointsMax {
-			pointsMax = v
+
+	// максимальные баллы - сумма обычных правил vs максимальное из правил Maximum
+	return result.result(), nil
+}
+
+// Проверка структуры заказа: total - число, items - массив объектов с числовым price
+func ValidateOrder(order map[string]any) error {
+	if _, err := orderNumber(order, "total"); err != nil {
+		return err
+	}
+	items, err := orderItems(order)
+	if err != nil {
+		return err
+	}
+	for n, i := range items {
+		if _, err := orderNumber(i, "price"); err != nil {
+			return fmt.Errorf("items[%d]: %w", n, err)
 		}
 	}
+	return nil
+}
 
-	// возвращаем максимальные баллы - сумма обычных правил vs максимальное из правил Maximum
-	if pointsAll > pointsMax {
-		return pointsAll
-	} else {
-		return pointsMax
+// числовое поле заказа или позиции
+func orderNumber(data map[string]any, field string) (float64, error) {
+	v, ok := data[field]
+	if !ok || v == nil {
+		return 0, fmt.Errorf("%w: field %s is required", models.ErrMalformedOrder, field)
+	}
+	f, ok := toFloat64(v)
+	if !ok {
+		return 0, fmt.Errorf("%w: field %s is not a number: %v", models.ErrMalformedOrder, field, v)
+	}
+	return f, nil
+}
+
+// позиции заказа, поле items необязательное
+func orderItems(order map[string]any) ([]map[string]any, error) {
+	v, ok := order["items"]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: field items is not an array", models.ErrMalformedOrder)
+	}
+	items := make([]map[string]any, 0, len(list))
+	for n, item := range list {
+		i, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: items[%d] is not an object", models.ErrMalformedOrder, n)
+		}
+		items = append(items, i)
+	}
+	return items, nil
+}
+
+// баллы как процент от суммы, округление вверх
+func percentPoints(value float64, percent int32) int32 {
+	return int32(math.Ceil(value * float64(percent) / 100))
+}
+
+// паника в горутине errgroup возвращается как ошибка
+func safe(fn func() error) func() error {
+	return func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return fn()
 	}
 }
 
@@ -94,17 +188,22 @@ func Relevant(ctx context.Context, order map[string]any, rule models.Rule) (poin
 	}
 	// Баллы для заголовка
 	if rule.Header.Percent != 0 {
-		total := order["total"].(float64)
-		points = int32(math.Ceil(total * float64(rule.Header.Percent) / 100))
+		total, err := orderNumber(order, "total")
+		if err != nil {
+			return 0, err
+		}
+		points = percentPoints(total, rule.Header.Percent)
 	} else {
 		points = rule.Header.Points
 	}
 	// Позиции
-	items, ok := order["items"].([]any)
-	if ok {
+	items, err := orderItems(order)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) > 0 {
 		g, errorctx := errgroup.WithContext(ctx)
-		for _, item := range items {
-			i := item.(map[string]any)
+		for _, i := range items {
 			for _, v := range rule.Items {
 				select {
 				case <-ctx.Done():
@@ -112,7 +211,7 @@ func Relevant(ctx context.Context, order map[string]any, rule models.Rule) (poin
 				default:
 					i := i
 					v := v
-					g.Go(func() error {
+					g.Go(safe(func() error {
 						select {
 						case <-errorctx.Done():
 							return nil
@@ -122,9 +221,12 @@ func Relevant(ctx context.Context, order map[string]any, rule models.Rule) (poin
 								return err
 							}
 							if ok {
-								price := i["price"].(float64)
 								if v.Percent != 0 {
-									p := int32(math.Ceil(price * float64(v.Percent) / 100))
+									price, err := orderNumber(i, "price")
+									if err != nil {
+										return err
+									}
+									p := percentPoints(price, v.Percent)
 									atomic.AddInt32(&points, p)
 								} else {
 									atomic.AddInt32(&points, int32(v.Points))
@@ -132,11 +234,14 @@ func Relevant(ctx context.Context, order map[string]any, rule models.Rule) (poin
 							}
 							return nil
 						}
-					})
+					}))
 				}
 			}
 		}
 		if err := g.Wait(); err != nil {
+			if errors.Is(err, models.ErrMalformedOrder) {
+				return 0, err
+			}
 			return 0, fmt.Errorf("incorrect rule: %s, %w", rule.ID.String(), err)
 		}
 	}
@@ -161,7 +266,7 @@ func checkRewardCriteria(ctx context.Context, reward models.RewardCriteria, data
 	g, errorctx := errgroup.WithContext(ctx)
 
 	// Исключающие условия
-	g.Go(func() error {
+	g.Go(safe(func() error {
 		for _, v := range reward.Exclude {
 			select {
 			case <-errorctx.Done():
@@ -180,10 +285,10 @@ func checkRewardCriteria(ctx context.Context, reward models.RewardCriteria, data
 			}
 		}
 		return nil
-	})
+	}))
 
 	// Включающие условия
-	g.Go(func() error {
+	g.Go(safe(func() error {
 		var find bool
 		for _, v := range reward.Include {
 			select {
@@ -207,7 +312,7 @@ func checkRewardCriteria(ctx context.Context, reward models.RewardCriteria, data
 		}
 		include = find
 		return nil
-	})
+	}))
 
 	if err := g.Wait(); err != nil {
 		return false, err
@@ -313,10 +418,13 @@ func compareValues(value1, value2 any) (int, error) {
 		}
 	}
 
-	// числа
+	// числа, две строки сравниваются как строки
+	// строка с числом и число сравниваются как числа ("500" > 200), раньше такое условие не выполнялось
+	_, str1 := value1.(string)
+	_, str2 := value2.(string)
 	numvalue1, value1ok := toFloat64(value1)
 	numvalue2, value2ok := toFloat64(value2)
-	if value1ok && value2ok {
+	if value1ok && value2ok && !(str1 && str2) {
 		switch {
 		case numvalue1 > numvalue2:
 			return 1, nil
@@ -356,13 +464,52 @@ func compareValues(value1, value2 any) (int, error) {
 	return 0, fmt.Errorf("compare is impossible")
 }
 
-// преобразование в float64
+// преобразование в float64: целые и дробные числа, json.Number, числовые строки
 func toFloat64(a any) (float64, bool) {
+	var f float64
 	switch val := a.(type) {
 	case int:
-		return float64(val), true
+		f = float64(val)
+	case int8:
+		f = float64(val)
+	case int16:
+		f = float64(val)
+	case int32:
+		f = float64(val)
+	case int64:
+		f = float64(val)
+	case uint:
+		f = float64(val)
+	case uint8:
+		f = float64(val)
+	case uint16:
+		f = float64(val)
+	case uint32:
+		f = float64(val)
+	case uint64:
+		f = float64(val)
+	case float32:
+		f = float64(val)
 	case float64:
-		return val, true
+		f = val
+	case json.Number:
+		v, err := strconv.ParseFloat(string(val), 64)
+		if err != nil {
+			return 0, false
+		}
+		f = v
+	case string:
+		v, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return 0, false
+		}
+		f = v
+	default:
+		return 0, false
+	}
+	// NaN и бесконечность не сравниваются
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
 	}
-	return 0, false
+	return f, true
 }
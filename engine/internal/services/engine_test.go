@@ -2,6 +2,12 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
 	"testing"
 
 	models "github.com/glkeru/loyalty/engine/internal/models"
@@ -28,6 +34,10 @@ func TestCompareValues(t *testing.T) {
 		{true, false, -1},
 		{"StrEqual", "StrEqual", 0},
 		{"StrEqual", "StrNotEqual", -1},
+		{"500", 200, 1},
+		{200, " 500 ", -1},
+		{json.Number("344.3"), "344.3", 0},
+		{"100", "99", -1}, // две строки - как строки
 	}
 
 	for _, ts := range tests {
@@ -46,6 +56,8 @@ func TestCompareValuesErrors(t *testing.T) {
 		{"2025-01-02", true, 1},
 		{"2025-01-02", 244.43, 1},
 		{false, 244.43, 1},
+		{"abc", 244.43, 1},
+		{"NaN", 244.43, 1},
 	}
 
 	for _, ts := range tests {
@@ -71,6 +83,9 @@ func TestCheckCondition(t *testing.T) {
 		{true, "=", false, false},
 		{"StrEqual", "=", "StrEqual", true},
 		{"StrEqual", "=", "StrNotEqual", false},
+		{"500", ">", 200, true},
+		{"500", "<", 200, false},
+		{344.3, "=", "344.3", true},
 	}
 
 	for _, ts := range tests {
@@ -86,11 +101,9 @@ type TestCase struct {
 	Data     map[string]any
 }
 
-func TestFull(t *testing.T) {
-	cont := gomock.NewController(t)
-	defer cont.Finish()
-
-	rules := []models.Rule{
+// правила для тестов расчета
+func testRules() []models.Rule {
+	return []models.Rule{
 		{
 			ID:      uuid.MustParse("11111111-1111-1111-1111-111111111111"),
 			Active:  true,
@@ -213,20 +226,28 @@ func TestFull(t *testing.T) {
 			},
 		},
 	}
+}
+
+// сервис расчета на тестовых правилах
+func testService(t testing.TB) *RuleEngineService {
+	cont := gomock.NewController(t)
 
 	tengine := NewMockRuleStorage(cont)
 	logger := zap.NewNop()
 
 	tengine.EXPECT().
 		GetActiveRules(gomock.Any()).
-		Return(rules, nil).
+		Return(testRules(), nil).
 		AnyTimes()
 
-	serv, err := NewRuleEngineService(tengine, logger)
+	serv, err := NewRuleEngineService(tengine, nil, logger)
 	if err != nil {
 		t.Fatalf("NewRuleEngineService() error = %v", err)
 	}
+	return serv
+}
 
+func TestFull(t *testing.T) {
 	// заказы для теста
 	tests := []TestCase{
 		{
@@ -307,13 +328,328 @@ func TestFull(t *testing.T) {
 		},
 	}
 
+	// результат не зависит от режима расчета
+	for _, mode := range []string{EVAL_CONCURRENT, EVAL_POOL, EVAL_SEQUENTIAL} {
+		serv := testService(t)
+		serv.Mode = mode
+		for _, ts := range tests {
+			ts := ts
+			t.Run(mode+"/"+ts.Name, func(t *testing.T) {
+				t.Parallel()
+				result, err := serv.Calculate(context.Background(), ts.Data)
+				require.NoError(t, err)
+				require.Equal(t, result, ts.Expected, "data=%v expected=%v result=%v", ts.Data, ts.Expected, result)
+			})
+		}
+	}
+
+}
+
+func TestToFloat64(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected float64
+		ok       bool
+	}{
+		{int(5), 5, true},
+		{int32(5), 5, true},
+		{int64(-5), -5, true},
+		{uint8(7), 7, true},
+		{float32(1.5), 1.5, true},
+		{float64(1.25), 1.25, true},
+		{json.Number("500.5"), 500.5, true},
+		{"100", 100, true},
+		{" 42 ", 42, true},
+		{"1e3", 1000, true},
+		{json.Number("abc"), 0, false},
+		{"abc", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"", 0, false},
+		{true, 0, false},
+		{nil, 0, false},
+		{map[string]any{}, 0, false},
+	}
+
+	for _, ts := range tests {
+		result, ok := toFloat64(ts.value)
+		require.Equal(t, ts.ok, ok, "value=%#v", ts.value)
+		require.Equal(t, ts.expected, result, "value=%#v", ts.value)
+	}
+}
+
+func TestCalculateCoercion(t *testing.T) {
+	serv := testService(t)
+
+	// числовые значения разных типов дают тот же результат, что и float64
+	tests := []TestCase{
+		{
+			Expected: 60,
+			Name:     "total строкой, price целым",
+			Data: map[string]any{
+				"total":     "500",
+				"orderdate": "2025-12-02",
+				"items": []any{
+					map[string]any{"productid": "MaxProduc2", "price": 500},
+				},
+			},
+		},
+		{
+			Expected: 250,
+			Name:     "json.Number",
+			Data: map[string]any{
+				"total":     json.Number("500"),
+				"orderdate": "2025-12-02",
+				"items": []any{
+					map[string]any{"productid": "MaxProduct", "price": json.Number("500")},
+				},
+			},
+		},
+	}
+
 	for _, ts := range tests {
-		ts := ts
 		t.Run(ts.Name, func(t *testing.T) {
-			t.Parallel()
-			result := serv.Calculate(context.Background(), ts.Data)
-			require.Equal(t, result, ts.Expected, "data=%v expected=%v result=%v", ts.Data, ts.Expected, result)
+			result, err := serv.Calculate(context.Background(), ts.Data)
+			require.NoError(t, err)
+			require.Equal(t, ts.Expected, result)
+		})
+	}
+}
+
+func TestCalculateMalformed(t *testing.T) {
+	serv := testService(t)
+
+	tests := []struct {
+		name   string
+		data   map[string]any
+		reason string
+	}{
+		{"нет total", map[string]any{"items": []any{}}, "total is required"},
+		{"total не число", map[string]any{"total": "много"}, "total is not a number"},
+		{"total объект", map[string]any{"total": map[string]any{"value": 1}}, "total is not a number"},
+		{"items не массив", map[string]any{"total": 1, "items": "a"}, "items is not an array"},
+		{"позиция не объект", map[string]any{"total": 1, "items": []any{1}}, "items[0] is not an object"},
+		{"нет price", map[string]any{"total": 1, "items": []any{map[string]any{"productid": "a"}}}, "items[0]: malformed order: field price is required"},
+		{"price строкой", map[string]any{"total": 1, "items": []any{map[string]any{"price": "дорого"}}}, "price is not a number"},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			_, err := serv.Calculate(context.Background(), ts.data)
+			require.ErrorIs(t, err, models.ErrMalformedOrder)
+			require.Contains(t, err.Error(), ts.reason)
+		})
+	}
+}
+
+// Расчет не должен паниковать на любом JSON: либо баллы, либо ErrMalformedOrder/ErrUnknownCurrency
+func FuzzCalculate(f *testing.F) {
+	f.Add(`{"total":5000,"jackpot":true,"orderdate":"2025-01-02","items":[{"productid":"MaxProduct","price":5000}]}`)
+	f.Add(`{"total":"500","items":[{"price":500,"productid":"BadProduct"}]}`)
+	f.Add(`{"total":1e308,"items":[{"price":-1e308}]}`)
+	f.Add(`{"total":null,"items":null}`)
+	f.Add(`{"total":1,"items":[null,[],{"price":{}}]}`)
+	f.Add(`{"total":1,"orderdate":1735689600000,"jackpot":"true"}`)
+
+	serv := testService(f)
+	f.Fuzz(func(t *testing.T, data string) {
+		order := make(map[string]any)
+		decoder := json.NewDecoder(strings.NewReader(data))
+		decoder.UseNumber()
+		if decoder.Decode(&order) != nil {
+			return
+		}
+		_, err := serv.Calculate(context.Background(), order)
+		if err != nil && !errors.Is(err, models.ErrUnknownCurrency) {
+			require.ErrorIs(t, err, models.ErrMalformedOrder)
+		}
+	})
+}
+
+// Сравнение значений не должно паниковать
+func FuzzCompareValues(f *testing.F) {
+	f.Add("2025-01-02", "2025-01-01", 1.5, int64(2))
+	f.Add("100", "99", 0.0, int64(0))
+	f.Add("NaN", "Inf", -0.0, int64(-1))
+
+	f.Fuzz(func(t *testing.T, s1 string, s2 string, n float64, i int64) {
+		values := []any{s1, s2, n, i, json.Number(s1), true, nil}
+		for _, v1 := range values {
+			for _, v2 := range values {
+				_, _ = compareValues(v1, v2)
+			}
+		}
+		if v, ok := toFloat64(s1); ok {
+			require.False(t, math.IsNaN(v) || math.IsInf(v, 0))
+		}
+	})
+}
+
+// Набор правил для бенчмарков: правила на заказ (процент, даты, флаги) и на позиции (категории, цена, исключения)
+func benchRules(count int) []models.Rule {
+	rules := make([]models.Rule, 0, count)
+	for n := 0; n < count; n++ {
+		rule := models.Rule{
+			ID:      uuid.New(),
+			Active:  true,
+			Maximum: n%5 == 0,
+			Name:    fmt.Sprintf("rule %d", n),
+			Header: models.RewardCriteria{
+				Include: []models.Criteria{
+					{
+						Operator: "AND",
+						Conditions: []models.Condition{
+							{Field: "total", Operator: ">=", Value: 100 * (n % 10)},
+							{Field: "orderdate", Operator: ">=", Value: "2025-01-01"},
+						},
+					},
+					{
+						Operator: "OR",
+						Conditions: []models.Condition{
+							{Field: "channel", Operator: "=", Value: "online"},
+							{Field: "city", Operator: "=", Value: fmt.Sprintf("city%d", n%3)},
+						},
+					},
+				},
+				Exclude: []models.Criteria{
+					{
+						Operator: "OR",
+						Conditions: []models.Condition{
+							{Field: "employee", Operator: "=", Value: true},
+						},
+					},
+				},
+			},
+		}
+		// четные правила - процент от заказа, нечетные - баллы за позиции категории
+		if n%2 == 0 {
+			rule.Header.Percent = int32(1 + n%10)
+		} else {
+			rule.Items = []models.RewardCriteria{
+				{
+					Percent: 5,
+					Include: []models.Criteria{
+						{
+							Operator: "AND",
+							Conditions: []models.Condition{
+								{Field: "category", Operator: "=", Value: fmt.Sprintf("cat%d", n%7)},
+								{Field: "price", Operator: ">", Value: 10},
+							},
+						},
+					},
+					Exclude: []models.Criteria{
+						{
+							Operator: "OR",
+							Conditions: []models.Condition{
+								{Field: "promo", Operator: "=", Value: true},
+							},
+						},
+					},
+				},
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// заказ с items позициями
+func benchOrder(items int) map[string]any {
+	list := make([]any, 0, items)
+	var total float64
+	for n := 0; n < items; n++ {
+		price := float64(50 + 37*n%500)
+		total += price
+		list = append(list, map[string]any{
+			"itemid":   float64(n),
+			"category": fmt.Sprintf("cat%d", n%7),
+			"price":    price,
+			"promo":    n%4 == 0,
 		})
 	}
+	return map[string]any{
+		"total":     total,
+		"orderdate": "2025-06-01",
+		"channel":   "online",
+		"city":      "city1",
+		"employee":  false,
+		"items":     list,
+	}
+}
+
+func benchService(mode string, rules int) *RuleEngineService {
+	return &RuleEngineService{
+		Rules:   benchRules(rules),
+		Mode:    mode,
+		Workers: runtime.NumCPU(),
+		logger:  zap.NewNop(),
+	}
+}
+
+// Сравнение режимов расчета: небольшой и крупный заказ, мало и много правил
+func BenchmarkCalculate(b *testing.B) {
+	sizes := []struct {
+		rules int
+		items int
+	}{
+		{5, 3},
+		{20, 10},
+		{50, 50},
+	}
+	for _, mode := range []string{EVAL_CONCURRENT, EVAL_POOL, EVAL_SEQUENTIAL} {
+		for _, size := range sizes {
+			serv := benchService(mode, size.rules)
+			order := benchOrder(size.items)
+			b.Run(fmt.Sprintf("%s/rules=%d/items=%d", mode, size.rules, size.items), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_, err := serv.Calculate(context.Background(), order)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// Сравнение режимов при параллельных запросах
+func BenchmarkCalculateParallel(b *testing.B) {
+	for _, mode := range []string{EVAL_CONCURRENT, EVAL_POOL, EVAL_SEQUENTIAL} {
+		serv := benchService(mode, 20)
+		order := benchOrder(10)
+		b.Run(mode, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, err := serv.Calculate(context.Background(), order)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// Режимы расчета дают одинаковый результат на наборе для бенчмарков
+func TestEvalModesEqual(t *testing.T) {
+	order := benchOrder(25)
+	var expected int32
+	for n, mode := range []string{EVAL_CONCURRENT, EVAL_POOL, EVAL_SEQUENTIAL} {
+		serv := &RuleEngineService{Rules: benchRules(30), Mode: mode, Workers: 4, logger: zap.NewNop()}
+		result, err := serv.Calculate(context.Background(), order)
+		require.NoError(t, err)
+		if n == 0 {
+			expected = result
+			require.NotZero(t, expected)
+		}
+		require.Equal(t, expected, result, "mode=%s", mode)
+	}
+}
 
+func TestSharedPoolSize(t *testing.T) {
+	// пул переиспользуется только сервисами с тем же размером
+	require.Same(t, sharedPool(2), sharedPool(2))
+	require.NotSame(t, sharedPool(2), sharedPool(3))
 }
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+
+	models "github.com/glkeru/loyalty/engine/internal/models"
+)
+
+// Режимы расчета
+const (
+	EVAL_CONCURRENT = "concurrent" // горутина на правило, позицию и условие
+	EVAL_POOL       = "pool"       // правила рассчитываются общим для всех запросов пулом горутин
+	EVAL_SEQUENTIAL = "sequential" // правила рассчитываются последовательно в горутине запроса
+)
+
+// режим расчета и размер пула из окружения
+func evalConfig() (mode string, workers int) {
+	// TODO DEFAULT
+	mode = os.Getenv("ENGINE_EVAL_MODE")
+	switch mode {
+	case EVAL_POOL, EVAL_SEQUENTIAL:
+	default:
+		mode = EVAL_CONCURRENT
+	}
+	// TODO DEFAULT
+	workers = runtime.NumCPU()
+	workersenv := os.Getenv("ENGINE_EVAL_WORKERS")
+	if workersenv != "" {
+		w, err := strconv.Atoi(workersenv)
+		if err == nil && w > 0 {
+			workers = w
+		}
+	}
+	return mode, workers
+}
+
+// Пул горутин, общий для всех запросов: кол-во горутин расчета не зависит от кол-ва запросов
+type evalPool struct {
+	jobs chan func()
+}
+
+var (
+	pools   = make(map[int]*evalPool)
+	poolsMu sync.Mutex
+)
+
+// пул создается при первом расчете в режиме pool, отдельный пул на каждый размер
+func sharedPool(workers int) *evalPool {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	if p, ok := pools[workers]; ok {
+		return p
+	}
+	p := &evalPool{jobs: make(chan func())}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	pools[workers] = p
+	return p
+}
+
+// Расчет в режиме pool: правило - задача пула, правило рассчитывается последовательно
+func (s *RuleEngineService) calculatePool(ctx context.Context, orders map[string]map[string]any) map[string]int32 {
+	p := sharedPool(s.Workers)
+	wg := &sync.WaitGroup{}
+	result := newWalletPoints()
+
+	for _, rule := range s.Rules {
+		wg.Add(1)
+		job := func() {
+			defer wg.Done()
+			order, ok := s.orderFor(orders, rule)
+			if !ok || ctx.Err() != nil {
+				return
+			}
+			points, err := relevantSafe(ctx, order, rule)
+			if err != nil {
+				s.Log(err)
+				return
+			}
+			result.add(rule, points)
+		}
+		select {
+		case p.jobs <- job:
+		case <-ctx.Done():
+			wg.Done()
+		}
+	}
+	wg.Wait()
+	return result.result()
+}
+
+// Расчет в режиме sequential
+func (s *RuleEngineService) calculateSequential(ctx context.Context, orders map[string]map[string]any) map[string]int32 {
+	result := newWalletPoints()
+	for _, rule := range s.Rules {
+		if ctx.Err() != nil {
+			break
+		}
+		order, ok := s.orderFor(orders, rule)
+		if !ok {
+			continue
+		}
+		points, err := relevantSafe(ctx, order, rule)
+		if err != nil {
+			s.Log(err)
+			continue
+		}
+		result.add(rule, points)
+	}
+	return result.result()
+}
+
+// последовательный расчет правила, паника возвращается как ошибка правила
+func relevantSafe(ctx context.Context, order map[string]any, rule models.Rule) (points int32, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("incorrect rule: %s, panic: %v", rule.ID.String(), r)
+		}
+	}()
+	return RelevantSequential(ctx, order, rule)
+}
+
+// Расчет одного правила без горутин, результат совпадает с Relevant
+func RelevantSequential(ctx context.Context, order map[string]any, rule models.Rule) (points int32, err error) {
+	// Заголовок
+	ok, err := checkRewardCriteriaSequential(rule.Header, order)
+	if err != nil {
+		return 0, fmt.Errorf("incorrect rule: %s, %v", rule.ID.String(), err)
+	}
+	if !ok {
+		return 0, nil
+	}
+	// Баллы для заголовка
+	points, err = rewardPoints(rule.Header, order, "total")
+	if err != nil {
+		return 0, err
+	}
+	// Позиции
+	items, err := orderItems(order)
+	if err != nil {
+		return 0, err
+	}
+	for _, i := range items {
+		for _, v := range rule.Items {
+			if ctx.Err() != nil {
+				return 0, nil
+			}
+			ok, err := checkRewardCriteriaSequential(v, i)
+			if err != nil {
+				return 0, fmt.Errorf("incorrect rule: %s, %w", rule.ID.String(), err)
+			}
+			if ok {
+				p, err := rewardPoints(v, i, "price")
+				if err != nil {
+					return 0, err
+				}
+				points += p
+			}
+		}
+	}
+	return points, nil
+}
+
+// баллы по условию: процент от поля field или фиксированные баллы
+func rewardPoints(reward models.RewardCriteria, data map[string]any, field string) (int32, error) {
+	if reward.Percent == 0 {
+		return reward.Points, nil
+	}
+	value, err := orderNumber(data, field)
+	if err != nil {
+		return 0, err
+	}
+	return percentPoints(value, reward.Percent), nil
+}
+
+// Последовательная проверка наборов Exclude и Include
+func checkRewardCriteriaSequential(reward models.RewardCriteria, data map[string]any) (bool, error) {
+	if len(reward.Include) == 0 {
+		return false, fmt.Errorf("rule is empty")
+	}
+	// если хоть одно исключающее условие сработало, значит исключаем
+	for _, v := range reward.Exclude {
+		ok, err := checkCriteria(v, data)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return false, nil
+		}
+	}
+	// если хоть одно включающее условие не сработало, значит не подходит
+	for _, v := range reward.Include {
+		ok, err := checkCriteria(v, data)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"sync"
+
+	models "github.com/glkeru/loyalty/engine/internal/models"
+)
+
+// Итог расчета по кошелькам: сумма обычных правил конкурирует с наибольшим из правил Maximum
+type walletPoints struct {
+	mu        sync.Mutex
+	all       map[string]int32 // сумма баллов по обычным правилам по кошелькам
+	allTotal  int32
+	maxPoints int32  // наибольшее кол-во баллов среди правил с типом Maximum
+	maxWallet string // кошелек правила Maximum с наибольшим кол-вом баллов
+}
+
+func newWalletPoints() *walletPoints {
+	return &walletPoints{all: make(map[string]int32)}
+}
+
+// баллы по правилу
+func (w *walletPoints) add(rule models.Rule, points int32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if rule.Maximum {
+		if points > w.maxPoints {
+			w.maxPoints, w.maxWallet = points, rule.WalletCode()
+		}
+		return
+	}
+	if points != 0 {
+		w.all[rule.WalletCode()] += points
+		w.allTotal += points
+	}
+}
+
+// баллы по кошелькам: сумма обычных правил или наибольшее правило Maximum
+func (w *walletPoints) result() map[string]int32 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.allTotal > w.maxPoints {
+		return w.all
+	}
+	if w.maxPoints == 0 {
+		return map[string]int32{}
+	}
+	return map[string]int32{w.maxWallet: w.maxPoints}
+}
+
+// сумма баллов по всем кошелькам
+func totalPoints(wallets map[string]int32) (points int32) {
+	for _, p := range wallets {
+		points += p
+	}
+	return points
+}
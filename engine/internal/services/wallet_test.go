@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/glkeru/loyalty/engine/internal/models"
+	uuid "github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// правило: фиксированные баллы в кошелек, если total >= threshold
+func walletRule(wallet string, maximum bool, threshold float64, points int32) models.Rule {
+	return models.Rule{
+		ID:      uuid.New(),
+		Active:  true,
+		Maximum: maximum,
+		Name:    "rule " + wallet,
+		Wallet:  wallet,
+		Header: models.RewardCriteria{
+			Points: points,
+			Include: []models.Criteria{
+				{
+					Operator: "AND",
+					Conditions: []models.Condition{
+						{Field: "total", Operator: ">=", Value: threshold},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCalculateWallets(t *testing.T) {
+	rules := []models.Rule{
+		walletRule("", false, 0, 100),                    // основные баллы
+		walletRule(models.WALLET_PROMO, false, 1000, 50), // промо-баллы
+		walletRule(models.WALLET_MILES, true, 5000, 500), // мили партнера - правило Maximum
+	}
+
+	tests := []struct {
+		name     string
+		total    float64
+		expected map[string]int32
+	}{
+		{"только основные", 500, map[string]int32{models.WALLET_BASE: 100}},
+		{"основные и промо", 1000, map[string]int32{models.WALLET_BASE: 100, models.WALLET_PROMO: 50}},
+		{"Maximum больше суммы", 5000, map[string]int32{models.WALLET_MILES: 500}},
+	}
+
+	for _, mode := range []string{EVAL_CONCURRENT, EVAL_POOL, EVAL_SEQUENTIAL} {
+		serv := &RuleEngineService{Rules: rules, Mode: mode, Workers: 2, logger: zap.NewNop()}
+		for _, ts := range tests {
+			t.Run(mode+"/"+ts.name, func(t *testing.T) {
+				wallets, err := serv.CalculateWallets(context.Background(), map[string]any{"total": ts.total})
+				require.NoError(t, err)
+				require.Equal(t, ts.expected, wallets)
+
+				points, err := serv.Calculate(context.Background(), map[string]any{"total": ts.total})
+				require.NoError(t, err)
+				require.Equal(t, totalPoints(ts.expected), points)
+			})
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
@@ -49,6 +50,11 @@ func InitTracer(ctx context.Context) func() {
 		sdktrace.WithResource(res),
 	)
 	otel.SetTracerProvider(tp)
+	// контекст трассировки вызывающего сервиса из заголовка traceparent
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
 
 	// тестовый спан при старте
 	tr := otel.Tracer("engine")
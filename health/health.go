@@ -0,0 +1,172 @@
+// Проверки состояния сервиса для Kubernetes: liveness /healthz и readiness /readyz
+// с результатом по каждой зависимости (БД, кэш, брокеры сообщений)
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Статусы проверок
+const (
+	STATUS_OK       = "ok"
+	STATUS_FAIL     = "fail"
+	STATUS_SHUTDOWN = "shutting down"
+)
+
+// время ожидания проверки зависимости
+const checkTimeout = 2 * time.Second
+
+// Проверка зависимости, nil - зависимость доступна
+type Check func(ctx context.Context) error
+
+// Результат проверок: общий статус и статус или ошибка по каждой зависимости
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type Checker struct {
+	mu       sync.RWMutex
+	checks   map[string]dependency
+	timeout  time.Duration
+	shutdown atomic.Bool
+}
+
+type dependency struct {
+	check    Check
+	optional bool
+}
+
+func New() *Checker {
+	return &Checker{checks: make(map[string]dependency), timeout: checkTimeout}
+}
+
+// Добавление проверки зависимости name, повторное добавление заменяет проверку
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = dependency{check: check}
+}
+
+// Добавление необязательной зависимости (кэш): ошибка проверки есть в ответе, но не меняет статус
+func (c *Checker) AddOptional(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = dependency{check: check, optional: true}
+}
+
+// Начало остановки: сервис больше не готов принимать запросы, liveness не меняется
+func (c *Checker) Shutdown() {
+	c.shutdown.Store(true)
+}
+
+// Задержка между началом остановки и закрытием listener, сек (<prefix>_SHUTDOWN_DELAY):
+// балансировщик должен увидеть неготовность хотя бы одной проверкой readiness, иначе запросы приходят на закрытый порт
+func DrainDelay(prefix string) time.Duration {
+	// TODO DEFAULT
+	delay, err := strconv.Atoi(os.Getenv(prefix + "_SHUTDOWN_DELAY"))
+	if err != nil || delay < 0 {
+		delay = 15
+	}
+	return time.Duration(delay) * time.Second
+}
+
+// Выполнение проверок параллельно, каждая ограничена timeout
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make(map[string]dependency, len(c.checks))
+	for name, dep := range c.checks {
+		checks[name] = dep
+	}
+	c.mu.RUnlock()
+
+	report := Report{Status: STATUS_OK, Checks: make(map[string]string, len(checks))}
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for name, dep := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			err := dep.check(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !dep.optional {
+					report.Status = STATUS_FAIL
+				}
+				report.Checks[name] = err.Error()
+				return
+			}
+			report.Checks[name] = STATUS_OK
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// Готовность: все зависимости доступны и остановка не начата
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := c.Run(ctx)
+	if c.shutdown.Load() {
+		report.Status = STATUS_SHUTDOWN
+	}
+	return report
+}
+
+// Liveness: процесс работает - всегда 200, зависимости не проверяются,
+// чтобы Kubernetes не перезапускал сервис при сбое БД или брокера; состояние зависимостей - в /readyz
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write(w, http.StatusOK, Report{Status: STATUS_OK})
+	})
+}
+
+// Readiness: 503, если зависимость недоступна или начата остановка
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+		code := http.StatusOK
+		if report.Status != STATUS_OK {
+			code = http.StatusServiceUnavailable
+		}
+		write(w, code, report)
+	})
+}
+
+// Маршруты GET /healthz и GET /readyz
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.Handle("GET /healthz", c.LivenessHandler())
+	mux.Handle("GET /readyz", c.ReadinessHandler())
+}
+
+// Периодическая проверка готовности для внешних проверок состояния (gRPC health)
+// set вызывается при каждой проверке до отмены ctx
+func (c *Checker) Watch(ctx context.Context, interval time.Duration, set func(ready bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		set(c.Ready(ctx).Status == STATUS_OK)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func write(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, mux *http.ServeMux, path string) (int, Report) {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var report Report
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	return rec.Code, report
+}
+
+func TestChecker(t *testing.T) {
+	checker := New()
+	mux := http.NewServeMux()
+	checker.Register(mux)
+
+	var redisErr error
+	checker.Add("postgres", func(ctx context.Context) error { return nil })
+	checker.Add("redis", func(ctx context.Context) error { return redisErr })
+
+	// liveness не выполняет проверки
+	checker.Add("broker", func(ctx context.Context) error { panic("liveness must not run checks") })
+	code, report := get(t, mux, "/healthz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, Report{Status: STATUS_OK}, report)
+	checker.Add("broker", func(ctx context.Context) error { return nil })
+
+	code, report = get(t, mux, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, Report{Status: STATUS_OK, Checks: map[string]string{"broker": STATUS_OK, "postgres": STATUS_OK, "redis": STATUS_OK}}, report)
+
+	// зависимость недоступна: сервис не готов, но жив
+	redisErr = errors.New("connection refused")
+	code, report = get(t, mux, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, STATUS_FAIL, report.Status)
+	require.Equal(t, "connection refused", report.Checks["redis"])
+	code, report = get(t, mux, "/healthz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, Report{Status: STATUS_OK}, report)
+
+	// ошибка необязательной зависимости не меняет статус
+	redisErr = nil
+	checker.AddOptional("cache", func(ctx context.Context) error { return errors.New("cache is down") })
+	code, report = get(t, mux, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "cache is down", report.Checks["cache"])
+
+	// остановка: readiness выключается при доступных зависимостях
+	checker.Shutdown()
+	code, report = get(t, mux, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, STATUS_SHUTDOWN, report.Status)
+	code, _ = get(t, mux, "/healthz")
+	require.Equal(t, http.StatusOK, code)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	checker := New()
+	checker.timeout = 20 * time.Millisecond
+	checker.Add("kafka", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	start := time.Now()
+	report := checker.Run(context.Background())
+	require.Equal(t, STATUS_FAIL, report.Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["kafka"])
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestWatch(t *testing.T) {
+	checker := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	states := make(chan bool, 10)
+	go checker.Watch(ctx, 10*time.Millisecond, func(ready bool) { states <- ready })
+	require.True(t, <-states)
+
+	checker.Shutdown()
+	require.Eventually(t, func() bool { return !<-states }, time.Second, time.Millisecond)
+	cancel()
+}
+
+func TestDrainDelay(t *testing.T) {
+	t.Setenv("TEST_SHUTDOWN_DELAY", "")
+	require.Equal(t, 15*time.Second, DrainDelay("TEST"))
+	t.Setenv("TEST_SHUTDOWN_DELAY", "0")
+	require.Equal(t, time.Duration(0), DrainDelay("TEST"))
+	t.Setenv("TEST_SHUTDOWN_DELAY", "30")
+	require.Equal(t, 30*time.Second, DrainDelay("TEST"))
+}
@@ -4,12 +4,15 @@ package main
 
 import (
 	"context"
+	"os"
+	"time"
 
 	"go.uber.org/zap"
 
 	db "github.com/glkeru/loyalty/points/internal/db"
 	interf "github.com/glkeru/loyalty/points/internal/interfaces"
 	services "github.com/glkeru/loyalty/points/internal/services"
+	metrics "github.com/glkeru/loyalty/points/observability/metrics"
 )
 
 func main() {
@@ -33,13 +36,49 @@ func main() {
 	redis, err = db.NewCacheService()
 	if err != nil {
 		logger.Error(err.Error())
+		redis = nil
 	}
 
 	serv := services.NewPointService(logger, storage, redis)
-	err = serv.CommitOnDate(context.Background())
+	start := time.Now()
+	result, err := serv.CommitOnDate(context.Background())
 	if err != nil {
 		logger.Error(err.Error())
-		return
+		// задание не выполнено: продолжительность и признак ошибки отправляются до выхода
+		metrics.CommitJobDuration.Set(time.Since(start).Seconds())
+		metrics.CommitJobFailed.Set(1)
+		pushMetrics(logger)
+		os.Exit(1)
+	}
+	var points float64
+	for _, a := range result.Accounts {
+		points += a.Points
+	}
+
+	// метрики задания
+	metrics.CommitJobDuration.Set(time.Since(start).Seconds())
+	metrics.CommitJobAccounts.WithLabelValues("committed").Set(float64(len(result.Accounts)))
+	metrics.CommitJobAccounts.WithLabelValues("failed").Set(float64(result.Failed))
+	metrics.CommitJobAccounts.WithLabelValues("cache_failed").Set(float64(result.CacheFailed))
+	metrics.CommitJobFailed.Set(0)
+	pushMetrics(logger)
+	logger.Info("Job Tnx commit on date is finished",
+		zap.Int("accounts", len(result.Accounts)),
+		zap.Int("users", len(result.Users())),
+		zap.Float64("points", points),
+		zap.Int("failed", result.Failed),
+		zap.Int("cacheFailed", result.CacheFailed))
+
+	// ошибки по отдельным счетам - код завершения для планировщика
+	if result.Failed > 0 || result.CacheFailed > 0 {
+		os.Exit(2)
+	}
+}
+
+// задание разовое, метрики отправляются в Pushgateway
+func pushMetrics(logger *zap.Logger) {
+	err := metrics.Push("commit_points")
+	if err != nil {
+		logger.Error("Metrics push error", zap.Error(err))
 	}
-	logger.Info("Job Tnx commit on date is finished")
 }
@@ -0,0 +1,39 @@
+// Job - повторная отправка сообщений из DLQ (<topic>.dlq) в исходный топик после устранения причины ошибки
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	kafka "github.com/glkeru/loyalty/points/internal/external/kafka"
+)
+
+func main() {
+	topic := flag.String("topic", "orders", "исходный топик: orders, returns")
+	limit := flag.Int("limit", 0, "максимальное кол-во сообщений, 0 - все")
+	idle := flag.Duration("idle", 10*time.Second, "завершить, если новых сообщений нет дольше")
+	flag.Parse()
+
+	// log
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	count, err := kafka.Replay(ctx, *topic, *limit, *idle)
+	if err != nil {
+		logger.Error(err.Error(), zap.Int("replayed", count))
+		os.Exit(1)
+	}
+	logger.Info("DLQ replay is finished", zap.String("topic", *topic), zap.Int("replayed", count))
+}
@@ -0,0 +1,55 @@
+// Job - отчет по журналу проводок для финансовой отчетности
+// Выводит в stdout JSON: оборотно-сальдовую ведомость и расхождения остатков счетов с журналом
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"go.uber.org/zap"
+
+	db "github.com/glkeru/loyalty/points/internal/db"
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	services "github.com/glkeru/loyalty/points/internal/services"
+)
+
+func main() {
+	// log
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	// database
+	var storage interf.PointsStorage
+	dt, err := db.NewPointsDB(logger)
+	if err != nil {
+		panic(err)
+	}
+	storage = dt
+
+	serv := services.NewPointService(logger, storage, nil)
+	report, err := serv.LedgerReport(context.Background())
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(report)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if !report.Consistent() {
+		logger.Error("Ledger is inconsistent",
+			zap.Float64("total", report.Total),
+			zap.Int("drifts", len(report.Drifts)))
+		os.Exit(2)
+	}
+	logger.Info("Job ledger report is finished")
+}
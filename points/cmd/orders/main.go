@@ -7,13 +7,15 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
-	"sync"
 	"syscall"
 
+	"github.com/glkeru/loyalty/health"
 	db "github.com/glkeru/loyalty/points/internal/db"
 	kafka "github.com/glkeru/loyalty/points/internal/external/kafka"
 	interf "github.com/glkeru/loyalty/points/internal/interfaces"
 	services "github.com/glkeru/loyalty/points/internal/services"
+	metrics "github.com/glkeru/loyalty/points/observability/metrics"
+	tracing "github.com/glkeru/loyalty/points/observability/otel"
 	"go.uber.org/zap"
 )
 
@@ -26,12 +28,20 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// observability
+	traceShutdown := tracing.InitTracer(context.Background(), "points-orders")
+	defer traceShutdown()
+	checker := health.New()
+	metricsShutdown := metrics.Serve(logger, checker)
+	defer metricsShutdown()
+
 	// kafka
-	reader, err := kafka.GetNewReader("orders")
+	reader, err := kafka.GetNewReader("orders", services.IsRetryable)
 	if err != nil {
 		panic(err)
 	}
 	defer reader.CloseReader()
+	checker.Add("kafka", kafka.Ping)
 
 	// database
 	var storage interf.PointsStorage
@@ -40,12 +50,16 @@ func main() {
 		panic(err)
 	}
 	storage = dt
+	checker.Add("postgres", dt.Ping)
 
 	// cache
 	var redis interf.CacheStorage
-	redis, err = db.NewCacheService()
+	cache, err := db.NewCacheService()
 	if err != nil {
 		logger.Error(err.Error())
+	} else {
+		redis = cache
+		checker.AddOptional("redis", cache.Ping)
 	}
 
 	// services
@@ -73,37 +87,16 @@ func main() {
 		semcount = 1
 	}
 
-	wg := &sync.WaitGroup{}
-	semaphore := make(chan struct{}, semcount)
-
-loop:
-	for {
-		select {
-		case <-interrupt:
-			cancel()
-			break loop
-		case <-ctx.Done():
-			break loop
-		default:
-
-			order, err := reader.GetNewMessage(ctx)
-			if err != nil {
-				logger.Error(err.Error())
-				return
-			}
+	// os signals
+	go func() {
+		<-interrupt
+		checker.Shutdown()
+		cancel()
+	}()
 
-			semaphore <- struct{}{}
-			wg.Add(1)
-			go func(order string) {
-				defer wg.Done()
-				defer func() { <-semaphore }()
-				err = serv.OrderCalculate(ctx, order)
-				if err != nil {
-					logger.Error(err.Error())
-					return
-				}
-			}(order)
-		}
+	// offset коммитится после обработки, ошибки уходят в топик повторов или DLQ
+	err = reader.Consume(ctx, semcount, serv.OrderCalculate)
+	if err != nil {
+		logger.Error(err.Error())
 	}
-	wg.Wait()
 }
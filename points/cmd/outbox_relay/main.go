@@ -0,0 +1,99 @@
+// Job - отправка исходящих сообщений из outbox
+// Подтверждения списаний отправляются в RabbitMQ (очередь confirms), события - в Kafka (топики tiers, points.events)
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/glkeru/loyalty/health"
+	db "github.com/glkeru/loyalty/points/internal/db"
+	kafka "github.com/glkeru/loyalty/points/internal/external/kafka"
+	rabbit "github.com/glkeru/loyalty/points/internal/external/rabbitmq"
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	services "github.com/glkeru/loyalty/points/internal/services"
+	metrics "github.com/glkeru/loyalty/points/observability/metrics"
+)
+
+func main() {
+	// log
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	// метрики и проверки состояния: /healthz, /readyz
+	checker := health.New()
+	metricsShutdown := metrics.Serve(logger, checker)
+	defer metricsShutdown()
+
+	// database
+	var storage interf.PointsStorage
+	dt, err := db.NewPointsDB(logger)
+	if err != nil {
+		panic(err)
+	}
+	storage = dt
+	checker.Add("postgres", dt.Ping)
+
+	// rabbitmq
+	publisher, err := rabbit.NewRabbitPublisher()
+	if err != nil {
+		logger.Error(err.Error())
+		panic(err)
+	}
+	defer publisher.Close()
+	checker.Add("rabbitmq", publisher.Ping)
+
+	// kafka
+	writer, err := kafka.NewWriter()
+	if err != nil {
+		logger.Error(err.Error())
+		panic(err)
+	}
+	defer writer.Close()
+	checker.Add("kafka", kafka.Ping)
+
+	relay := services.NewOutboxRelay(logger, storage, map[string]interf.MessagePublisher{
+		model.OUTBOX_RABBITMQ: publisher,
+		model.OUTBOX_KAFKA:    writer,
+	})
+
+	// start
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// os signals
+	go func() {
+		<-interrupt
+		checker.Shutdown()
+		cancel()
+	}()
+
+	ticker := time.NewTicker(relay.Interval())
+	defer ticker.Stop()
+	for {
+		sent, err := relay.RelayAll(ctx)
+		if err != nil {
+			logger.Error(err.Error())
+		}
+		if sent > 0 {
+			logger.Info("Outbox messages are sent", zap.Int("sent", sent))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
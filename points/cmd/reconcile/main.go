@@ -0,0 +1,112 @@
+// Job - сверка балансов счетов с обработанными транзакциями
+// Пересчитывает ожидаемый баланс каждого счета по обработанным транзакциям (источник истины) и выводит
+// расхождения с балансом и журналом проводок в JSON или CSV.
+// С флагом -fix приводит баланс к транзакциям проводкой корректировки на сумму расхождения
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	db "github.com/glkeru/loyalty/points/internal/db"
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	services "github.com/glkeru/loyalty/points/internal/services"
+)
+
+func main() {
+	format := flag.String("format", "json", "формат отчета: json, csv")
+	out := flag.String("out", "", "файл отчета, по умолчанию stdout")
+	fix := flag.Bool("fix", false, "привести балансы к транзакциям проводками корректировки")
+	flag.Parse()
+
+	// log
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	if *format != "json" && *format != "csv" {
+		logger.Fatal("unknown report format", zap.String("format", *format))
+	}
+
+	// database
+	var storage interf.PointsStorage
+	dt, err := db.NewPointsDB(logger)
+	if err != nil {
+		panic(err)
+	}
+	storage = dt
+
+	serv := services.NewPointService(logger, storage, nil)
+	drifts, fixed, err := serv.Reconcile(context.Background(), *fix)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// отчет
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "csv" {
+		err = writeCSV(w, drifts)
+	} else {
+		err = writeJSON(w, drifts)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("Job reconcile is finished",
+		zap.Int("drifts", len(drifts)),
+		zap.Int("fixed", fixed))
+}
+
+func writeJSON(w io.Writer, drifts []model.BalanceDrift) error {
+	if drifts == nil {
+		drifts = []model.BalanceDrift{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(drifts)
+}
+
+func writeCSV(w io.Writer, drifts []model.BalanceDrift) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"account", "userId", "balance", "expected", "ledger", "difference"})
+	if err != nil {
+		return err
+	}
+	for _, d := range drifts {
+		err = cw.Write([]string{
+			d.Account.String(),
+			d.UserId,
+			strconv.FormatFloat(d.Balance, 'f', 2, 64),
+			strconv.FormatFloat(d.Expected, 'f', 2, 64),
+			strconv.FormatFloat(d.Ledger, 'f', 2, 64),
+			strconv.FormatFloat(d.Difference, 'f', 2, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -3,16 +3,27 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/glkeru/loyalty/health"
 	db "github.com/glkeru/loyalty/points/internal/db"
 	rabbit "github.com/glkeru/loyalty/points/internal/external/rabbitmq"
 	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
 	services "github.com/glkeru/loyalty/points/internal/services"
+	metrics "github.com/glkeru/loyalty/points/observability/metrics"
+	tracing "github.com/glkeru/loyalty/points/observability/otel"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -24,6 +35,13 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// observability
+	traceShutdown := tracing.InitTracer(context.Background(), "points-redeems")
+	defer traceShutdown()
+	checker := health.New()
+	metricsShutdown := metrics.Serve(logger, checker)
+	defer metricsShutdown()
+
 	// rabbitmq
 	reader, err := rabbit.NewRabbitConsumer()
 	if err != nil {
@@ -31,6 +49,7 @@ func main() {
 		panic(err)
 	}
 	defer reader.Close()
+	checker.Add("rabbitmq", reader.Ping)
 
 	// database
 	var storage interf.PointsStorage
@@ -40,12 +59,16 @@ func main() {
 		panic(err)
 	}
 	storage = dt
+	checker.Add("postgres", dt.Ping)
 
 	// cache
 	var redis interf.CacheStorage
-	redis, err = db.NewCacheService()
+	cache, err := db.NewCacheService()
 	if err != nil {
 		logger.Error(err.Error())
+	} else {
+		redis = cache
+		checker.AddOptional("redis", cache.Ping)
 	}
 
 	// services
@@ -76,20 +99,66 @@ func main() {
 	// os signals
 	go func() {
 		<-interrupt
+		checker.Shutdown()
 		cancel()
 	}()
 
+	// TODO DEFAULT
+	retries := 3
+	retriesenv := os.Getenv("POINTS_REDEEM_RETRIES")
+	if retriesenv != "" {
+		r, err := strconv.Atoi(retriesenv)
+		if err == nil && r > 0 {
+			retries = r
+		}
+	}
+	// TODO DEFAULT
+	backoff := 200
+	backoffenv := os.Getenv("POINTS_REDEEM_BACKOFF")
+	if backoffenv != "" {
+		b, err := strconv.Atoi(backoffenv)
+		if err == nil && b > 0 {
+			backoff = b
+		}
+	}
+	policy := retryPolicy{retries, time.Duration(backoff) * time.Millisecond}
+
+	// глубина очереди - отставание обработки
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+		for {
+			depth, err := reader.Depth()
+			if err != nil {
+				logger.Warn("Queue depth error", zap.Error(err))
+			} else {
+				metrics.ConsumerLag.WithLabelValues(rabbit.QUEUE_REDEEMS, "").Set(float64(depth))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
 	// workers
 	wg := &sync.WaitGroup{}
 	wg.Add(semcount)
 	for i := 0; i < semcount; i++ {
-		go worker(ctx, serv, wg, logger, reader)
+		go worker(ctx, serv, wg, logger, reader, policy)
 	}
 	wg.Wait()
 }
 
+// повторы при временных ошибках: attempts попыток, пауза backoff удваивается после каждой попытки
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+}
+
 // worker for rabbitmq messages
-func worker(ctx context.Context, serv *services.PointsService, wg *sync.WaitGroup, logger *zap.Logger, reader *rabbit.RabbitConsumer) {
+func worker(ctx context.Context, serv *services.PointsService, wg *sync.WaitGroup, logger *zap.Logger, reader *rabbit.RabbitConsumer, policy retryPolicy) {
 	defer wg.Done()
 	for {
 		select {
@@ -100,20 +169,85 @@ func worker(ctx context.Context, serv *services.PointsService, wg *sync.WaitGrou
 			if ok != true {
 				return
 			}
-			redeemId, err := serv.Redeem(ctx, string(msg.Body))
-			if err != nil {
-				logger.Error(err.Error())
-				if redeemId != "" {
-					_ = reader.Processed(ctx, redeemId, false)
-				}
-				continue
-			}
-			err = reader.Processed(ctx, redeemId, true)
-			if err != nil {
-				logger.Error(err.Error())
-				continue
-			}
+			handle(ctx, serv, logger, reader, msg, policy)
+		}
+	}
+}
+
+// обработка сообщения: ack после записи результата, временные ошибки повторяются,
+// неразбираемые сообщения и сообщения с исчерпанными повторами уходят в dead-letter
+func handle(ctx context.Context, serv *services.PointsService, logger *zap.Logger, reader *rabbit.RabbitConsumer, msg amqp.Delivery, policy retryPolicy) {
+	metrics.MessagesConsumed.WithLabelValues(rabbit.QUEUE_REDEEMS).Inc()
+	start := time.Now()
+	// обработка продолжает трассировку отправителя операции
+	ctx, span := otel.Tracer("points/redeems").Start(rabbit.MessageContext(ctx, msg), rabbit.QUEUE_REDEEMS+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", rabbit.QUEUE_REDEEMS),
+			attribute.String("messaging.message.id", msg.MessageId),
+		))
+	defer span.End()
+
+	var redeem *services.RedeemStruct
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		// подтверждение успешной обработки записывается в outbox вместе с операцией
+		redeem, err = serv.Redeem(ctx, string(msg.Body))
+		if !services.IsRetryable(err) || attempt >= policy.attempts {
+			break
+		}
+		logger.Warn("Redeem retry",
+			zap.Error(err),
+			zap.String("redeem", redeem.RedeemId),
+			zap.Int("attempt", attempt))
+		select {
+		case <-ctx.Done():
+		case <-time.After(policy.backoff << (attempt - 1)):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	metrics.ObserveMessage(rabbit.QUEUE_REDEEMS, start, err)
+
+	// остановка - сообщение вернется в очередь
+	if ctx.Err() != nil {
+		_ = msg.Nack(false, true)
+		return
+	}
 
+	switch {
+	case err == nil:
+		err = msg.Ack(false)
+	case errors.Is(err, model.ErrAlreadyExists):
+		// повторная доставка уже обработанного сообщения, подтверждение уже в outbox
+		logger.Warn(err.Error(), zap.String("redeem", redeem.RedeemId))
+		err = msg.Ack(false)
+	case errors.Is(err, model.ErrMalformed), services.IsRetryable(err):
+		logger.Error("Redeem is dead-lettered",
+			zap.Error(err),
+			zap.String("redeem", redeem.RedeemId),
+			zap.Int("attempts", attempt))
+		err = reader.DeadLetter(ctx, msg, err.Error(), attempt)
+	default:
+		// бизнес-ошибка: подтверждение неуспешной обработки
+		logger.Error(err.Error(), zap.String("redeem", redeem.RedeemId))
+		err = serv.RedeemFailed(ctx, redeem)
+		if err != nil {
+			logger.Error(err.Error())
+			err = reader.DeadLetter(ctx, msg, err.Error(), attempt)
+		} else {
+			err = msg.Ack(false)
 		}
 	}
+	if err != nil {
+		logger.Error(err.Error())
+	}
 }
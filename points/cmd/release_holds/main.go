@@ -0,0 +1,46 @@
+// Job - снятие просроченных резервов баллов
+// Если время жизни резерва истекло - резерв снимается, баллы возвращаются в доступный баланс
+package main
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	db "github.com/glkeru/loyalty/points/internal/db"
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	services "github.com/glkeru/loyalty/points/internal/services"
+)
+
+func main() {
+	// log
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	// database
+	var storage interf.PointsStorage
+	dt, err := db.NewPointsDB(logger)
+	if err != nil {
+		panic(err)
+	}
+	storage = dt
+
+	// cache
+	var redis interf.CacheStorage
+	redis, err = db.NewCacheService()
+	if err != nil {
+		logger.Error(err.Error())
+		redis = nil
+	}
+
+	serv := services.NewPointService(logger, storage, redis)
+	count, err := serv.ReleaseExpiredHolds(context.Background())
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	logger.Info("Job release expired holds is finished", zap.Int("released", count))
+}
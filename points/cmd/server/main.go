@@ -1,16 +1,23 @@
 // gRPC server - обработка запросов на получение баланса и истории транзакций
+// HTTP/JSON шлюз к gRPC, метрики Prometheus и проверки состояния на втором порту
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/glkeru/loyalty/auth"
+	"github.com/glkeru/loyalty/health"
 	serv "github.com/glkeru/loyalty/points/internal/api/grpc"
+	trace "github.com/glkeru/loyalty/points/observability/otel"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 )
 
 func main() {
@@ -20,6 +27,27 @@ func main() {
 	if port == "" {
 		panic("env POINTS_GRPC_PORT is not set")
 	}
+	httpport := os.Getenv("POINTS_HTTP_PORT")
+	if httpport == "" {
+		panic("env POINTS_HTTP_PORT is not set")
+	}
+	// аутентификация: JWT и/или mTLS, POINTS_AUTH=none - без проверки
+	authn, err := auth.NewAuthenticatorFromEnv("POINTS")
+	if err != nil {
+		panic(err)
+	}
+	serverTLS, err := auth.ServerTLS("POINTS", authn)
+	if err != nil {
+		panic(err)
+	}
+	// шлюз обращается к gRPC серверу по TLS, если он включен
+	gatewayTLS, err := auth.ClientTLS("POINTS_GATEWAY")
+	if err != nil {
+		panic(err)
+	}
+	if serverTLS != nil && gatewayTLS == nil {
+		panic("env POINTS_GATEWAY_TLS_CA is not set")
+	}
 	// log
 	logger, err := zap.NewDevelopment()
 	if err != nil {
@@ -35,8 +63,18 @@ func main() {
 	interrrupt := make(chan os.Signal, 1)
 	signal.Notify(interrrupt, os.Interrupt, syscall.SIGTERM)
 
-	grpcServer := grpc.NewServer()
-	serv.RegisterGetPointsServer(grpcServer, serv.NewPointsService(logger))
+	traceShutdown := trace.InitTracer(context.Background(), "points")
+	defer traceShutdown()
+
+	// проверки состояния: HTTP /healthz, /readyz и gRPC grpc.health.v1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker := health.New()
+
+	grpcServer := serv.NewServer(logger, authn, serverTLS)
+	points := serv.NewPointsService(logger, checker)
+	serv.RegisterGetPointsServer(grpcServer, points)
+	healthShutdown := serv.RegisterHealth(ctx, grpcServer, checker)
 
 	go func() {
 		err := grpcServer.Serve(lis)
@@ -45,6 +83,32 @@ func main() {
 		}
 	}()
 
+	// HTTP/JSON gateway
+	gateway, err := serv.NewGateway(ctx, "localhost:"+port, gatewayTLS, authn, checker)
+	if err != nil {
+		panic(err)
+	}
+	httpServer := &http.Server{
+		Addr:              "0.0.0.0:" + httpport,
+		Handler:           gateway,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP gateway failed: %v", err)
+		}
+	}()
+
 	<-interrrupt
+	// сервис не готов: балансировщик перестает направлять запросы, текущие запросы завершаются
+	checker.Shutdown()
+	healthShutdown()
+	time.Sleep(health.DrainDelay("POINTS"))
+	// потоки WatchBalance не завершаются сами, иначе GracefulStop ждет их бесконечно
+	points.Close()
+	shutdown, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+	httpServer.Shutdown(shutdown)
 	grpcServer.GracefulStop()
 }
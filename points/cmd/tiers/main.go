@@ -0,0 +1,46 @@
+// Job - пересчет уровней статуса клиентов по активности за период
+// Повышение/понижение уровня записывается в историю, событие изменения записывается в outbox (топик tiers)
+package main
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	db "github.com/glkeru/loyalty/points/internal/db"
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	services "github.com/glkeru/loyalty/points/internal/services"
+)
+
+func main() {
+	// log
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	// database
+	var storage interf.PointsStorage
+	dt, err := db.NewPointsDB(logger)
+	if err != nil {
+		panic(err)
+	}
+	storage = dt
+
+	// cache
+	var redis interf.CacheStorage
+	redis, err = db.NewCacheService()
+	if err != nil {
+		logger.Error(err.Error())
+		redis = nil
+	}
+
+	serv := services.NewTierService(logger, storage, redis)
+	changed, err := serv.EvaluateTiers(context.Background())
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	logger.Info("Job tiers evaluation is finished", zap.Int("changed", changed))
+}
@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	context "context"
+
+	"github.com/glkeru/loyalty/auth"
+	codes "google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	status "google.golang.org/grpc/status"
+)
+
+// Права на методы сервиса: чтение баланса и истории, операции списания, ручная корректировка
+// метод без записи в таблице запрещен; проверка состояния доступна без учетных данных
+var MethodScopes = map[string]string{
+	GetPoints_GetBalance_FullMethodName:       auth.SCOPE_POINTS_READ,
+	GetPoints_WatchBalance_FullMethodName:     auth.SCOPE_POINTS_READ,
+	GetPoints_GetTnx_FullMethodName:           auth.SCOPE_POINTS_READ,
+	GetPoints_ListTransactions_FullMethodName: auth.SCOPE_POINTS_READ,
+	GetPoints_QuoteRedeem_FullMethodName:      auth.SCOPE_POINTS_READ,
+	GetPoints_HoldPoints_FullMethodName:       auth.SCOPE_POINTS_WRITE,
+	GetPoints_CaptureHold_FullMethodName:      auth.SCOPE_POINTS_WRITE,
+	GetPoints_ReleaseHold_FullMethodName:      auth.SCOPE_POINTS_WRITE,
+	GetPoints_Redeem_FullMethodName:           auth.SCOPE_POINTS_WRITE,
+	GetPoints_Transfer_FullMethodName:         auth.SCOPE_POINTS_WRITE,
+	GetPoints_Adjust_FullMethodName:           auth.SCOPE_POINTS_ADMIN,
+	healthpb.Health_Check_FullMethodName:      auth.PUBLIC,
+	healthpb.Health_List_FullMethodName:       auth.PUBLIC,
+	healthpb.Health_Watch_FullMethodName:      auth.PUBLIC,
+}
+
+// Доступ к данным пользователя при чтении: клиент с points:read получает только свои данные (user = sub токена),
+// межсервисные вызовы и поддержка (points:write) - данные любого пользователя
+func checkUser(ctx context.Context, user string) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		// аутентификация выключена
+		return nil
+	}
+	err := p.RequireUser(user, auth.SCOPE_POINTS_WRITE)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/glkeru/loyalty/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	status "google.golang.org/grpc/status"
+)
+
+// у каждого метода сервиса есть право, иначе метод недоступен
+func TestMethodScopes(t *testing.T) {
+	var methods []string
+	for _, desc := range []grpc.ServiceDesc{GetPoints_ServiceDesc, healthpb.Health_ServiceDesc} {
+		for _, m := range desc.Methods {
+			methods = append(methods, "/"+desc.ServiceName+"/"+m.MethodName)
+		}
+		for _, s := range desc.Streams {
+			methods = append(methods, "/"+desc.ServiceName+"/"+s.StreamName)
+		}
+	}
+	require.Len(t, MethodScopes, len(methods))
+	for _, method := range methods {
+		require.Contains(t, MethodScopes, method)
+	}
+}
+
+// клиент с points:read читает только свои данные
+func TestCheckUser(t *testing.T) {
+	serv := &PointsService{}
+	client := auth.NewContext(context.Background(), auth.NewPrincipal("u1", auth.METHOD_JWT, nil, []string{auth.ROLE_CLIENT}))
+	require.NoError(t, checkUser(client, "u1"))
+
+	_, err := serv.GetBalance(client, &BalanceRequest{User: "u2"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = serv.ListTransactions(client, &ListTransactionsRequest{User: "u2"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	service := auth.NewContext(context.Background(), auth.NewPrincipal("orders", auth.METHOD_MTLS, nil, []string{auth.ROLE_SERVICE}))
+	require.NoError(t, checkUser(service, "u2"))
+	// аутентификация выключена
+	require.NoError(t, checkUser(context.Background(), "u2"))
+}
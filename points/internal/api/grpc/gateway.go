@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	context "context"
+	"crypto/tls"
+	_ "embed"
+	"net/http"
+
+	"github.com/glkeru/loyalty/auth"
+	"github.com/glkeru/loyalty/health"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// спецификация OpenAPI, генерируется protoc-gen-openapiv2 из points.proto
+//
+//go:embed points.swagger.json
+var OpenAPI []byte
+
+// HTTP/JSON шлюз к gRPC серверу endpoint: маршруты из аннотаций google.api.http, спецификация /openapi.json и метрики /metrics
+// запросы проксируются по gRPC, поэтому поддерживаются и потоковые методы
+// config - TLS соединения с gRPC сервером, nil - без TLS; заголовок Authorization передается серверу
+// authn - запросы к методам сервиса без JWT отклоняются, чтобы они не выполнялись с правами сертификата шлюза, nil - без проверки
+// контекст трассировки из заголовка traceparent продолжается в gRPC
+// checker - проверки состояния /healthz, /readyz, nil - без проверок
+func NewGateway(ctx context.Context, endpoint string, config *tls.Config, authn *auth.Authenticator, checker *health.Checker) (http.Handler, error) {
+	gw := runtime.NewServeMux(
+		// нулевые значения (баланс 0) возвращаются явно
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+	)
+	creds := insecure.NewCredentials()
+	if config != nil {
+		creds = credentials.NewTLS(config)
+	}
+	err := RegisterGetPointsHandlerFromEndpoint(ctx, gw, endpoint, []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(OpenAPI)
+	})
+	mux.Handle("GET /metrics", promhttp.Handler())
+	if checker != nil {
+		checker.Register(mux)
+	}
+	mux.Handle("/", otelhttp.NewHandler(auth.RequireJWT(authn)(gw), "gateway"))
+	return mux, nil
+}
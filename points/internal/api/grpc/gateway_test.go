@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	context "context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// сервер с фиксированными ответами, фиксирует запросы
+type gatewayServer struct {
+	UnimplementedGetPointsServer
+	redeem *RedeemRequest
+}
+
+func (s *gatewayServer) GetBalance(ctx context.Context, in *BalanceRequest) (*BalanceResponse, error) {
+	if in.User != "u1" {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return &BalanceResponse{Points: 0, Tier: "BASIC", Wallets: []*WalletBalance{{Wallet: "BASE", Points: 0}}}, nil
+}
+
+func (s *gatewayServer) Redeem(ctx context.Context, in *RedeemRequest) (*RedeemResponse, error) {
+	s.redeem = in
+	return &RedeemResponse{Redeem: in.Redeem}, nil
+}
+
+func TestGateway(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	fake := &gatewayServer{}
+	RegisterGetPointsServer(server, fake)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gateway, err := NewGateway(ctx, lis.Addr().String(), nil, nil, nil)
+	require.NoError(t, err)
+	srv := httptest.NewServer(gateway)
+	defer srv.Close()
+
+	// баланс: нулевые значения возвращаются явно
+	resp, err := srv.Client().Get(srv.URL + "/v1/users/u1/balance")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var balance map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&balance))
+	resp.Body.Close()
+	require.Equal(t, float64(0), balance["points"])
+	require.Equal(t, "BASIC", balance["tier"])
+
+	// статус gRPC преобразуется в код HTTP
+	resp, err = srv.Client().Get(srv.URL + "/v1/users/u2/balance")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// операция записи: пользователь из пути, остальные поля из тела
+	resp, err = srv.Client().Post(srv.URL+"/v1/users/u1/redeems", "application/json",
+		strings.NewReader(`{"points": 10, "redeem": "r1", "wallet": "PROMO"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "u1", fake.redeem.User)
+	require.Equal(t, float64(10), fake.redeem.Points)
+	require.Equal(t, "PROMO", fake.redeem.Wallet)
+
+	// спецификация OpenAPI
+	resp, err = srv.Client().Get(srv.URL + "/openapi.json")
+	require.NoError(t, err)
+	spec, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Contains(t, string(spec), "/v1/users/{user}/transactions")
+}
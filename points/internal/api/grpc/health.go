@@ -0,0 +1,30 @@
+package grpc
+
+import (
+	context "context"
+	"time"
+
+	"github.com/glkeru/loyalty/health"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// период обновления статуса gRPC health
+const healthInterval = 5 * time.Second
+
+// Стандартный сервис проверки состояния grpc.health.v1: статус сервера ("") и points.GetPoints
+// по готовности checker до отмены ctx; возвращает функцию остановки - статус NOT_SERVING без дальнейших обновлений
+func RegisterHealth(ctx context.Context, server *grpc.Server, checker *health.Checker) func() {
+	hs := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, hs)
+	go checker.Watch(ctx, healthInterval, func(ready bool) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if ready {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		hs.SetServingStatus("", status)
+		hs.SetServingStatus(GetPoints_ServiceDesc.ServiceName, status)
+	})
+	return hs.Shutdown
+}
@@ -0,0 +1,182 @@
+package grpc
+
+import (
+	context "context"
+	"crypto/tls"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/glkeru/loyalty/auth"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	status "google.golang.org/grpc/status"
+)
+
+// метрики RED: кол-во запросов, ошибок и продолжительность по методу и коду ответа
+
+var (
+	grpcRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "points_grpc_requests_total",
+			Help: "Кол-во gRPC запросов",
+		},
+		[]string{"method", "code"},
+	)
+
+	grpcRequestsError = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "points_grpc_errors_total",
+			Help: "Кол-во ошибочных gRPC запросов",
+		},
+		[]string{"method", "code"},
+	)
+
+	grpcRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "points_grpc_request_duration_seconds",
+			Help:    "Продолжительность gRPC запросов, для потоков - время жизни потока",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "code"},
+	)
+)
+
+func UnaryServerMetrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func StreamServerMetrics() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observe(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observe(method string, start time.Time, err error) {
+	code := status.Code(err)
+	labels := prometheus.Labels{
+		"method": method,
+		"code":   code.String(),
+	}
+	grpcRequestsTotal.With(labels).Inc()
+	grpcRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+	if code != codes.OK {
+		grpcRequestsError.With(labels).Inc()
+	}
+}
+
+// журнал запросов: метод, код, продолжительность и ID трассировки
+// ошибки сервера - уровень Error, ошибки клиента (неверный запрос, нет баллов) - Info
+func UnaryServerLogger(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRequest(ctx, logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func StreamServerLogger(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logRequest(ss.Context(), logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logRequest(ctx context.Context, logger *zap.Logger, method string, start time.Time, err error) {
+	// проверки состояния выполняются каждые несколько секунд и не журналируются
+	if strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return
+	}
+	code := status.Code(err)
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("duration", time.Since(start)),
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		fields = append(fields, zap.String("trace_id", span.TraceID().String()))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded, codes.Unimplemented:
+		logger.Error("grpc request", fields...)
+	default:
+		logger.Info("grpc request", fields...)
+	}
+}
+
+// паника в обработчике не останавливает сервер: запрос завершается с кодом Internal
+func UnaryServerRecovery(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerRecovery(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(logger *zap.Logger, method string, r any) error {
+	logger.Error("grpc handler panic",
+		zap.String("method", method),
+		zap.Any("panic", r),
+		zap.ByteString("stack", debug.Stack()),
+	)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// gRPC сервер с трассировкой (контекст из метаданных запроса), метриками, журналом,
+// восстановлением после паники и проверкой прав; config - TLS, nil - без TLS
+func NewServer(logger *zap.Logger, authn *auth.Authenticator, config *tls.Config) *grpc.Server {
+	options := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			UnaryServerMetrics(),
+			UnaryServerLogger(logger),
+			UnaryServerRecovery(logger),
+			auth.UnaryServerInterceptor(authn, MethodScopes),
+		),
+		grpc.ChainStreamInterceptor(
+			StreamServerMetrics(),
+			StreamServerLogger(logger),
+			StreamServerRecovery(logger),
+			auth.StreamServerInterceptor(authn, MethodScopes),
+		),
+	}
+	if config != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(config)))
+	}
+	return grpc.NewServer(options...)
+}
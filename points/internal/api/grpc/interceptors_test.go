@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	context "context"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	status "google.golang.org/grpc/status"
+)
+
+// сервер с паникой в обработчике
+type panicServer struct {
+	UnimplementedGetPointsServer
+}
+
+func (s *panicServer) GetBalance(ctx context.Context, in *BalanceRequest) (*BalanceResponse, error) {
+	if in.User == "panic" {
+		panic("balance panic")
+	}
+	return &BalanceResponse{Points: 10}, nil
+}
+
+func TestServerInterceptors(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := NewServer(zap.New(core), nil, nil)
+	RegisterGetPointsServer(server, &panicServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := NewGetPointsClient(conn)
+
+	internal := grpcRequestsError.WithLabelValues(GetPoints_GetBalance_FullMethodName, codes.Internal.String())
+	before := testutil.ToFloat64(internal)
+
+	// паника - ответ Internal, сервер продолжает работу
+	_, err = client.GetBalance(context.Background(), &BalanceRequest{User: "panic"})
+	require.Equal(t, codes.Internal, status.Code(err))
+	resp, err := client.GetBalance(context.Background(), &BalanceRequest{User: "u1"})
+	require.NoError(t, err)
+	require.Equal(t, float64(10), resp.Points)
+
+	require.Equal(t, before+1, testutil.ToFloat64(internal))
+	require.Equal(t, 1, logs.FilterMessage("grpc handler panic").Len())
+	requests := logs.FilterMessage("grpc request").AllUntimed()
+	require.Len(t, requests, 2)
+	require.Equal(t, zap.ErrorLevel, requests[0].Level)
+	require.Equal(t, zap.InfoLevel, requests[1].Level)
+}
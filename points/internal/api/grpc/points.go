@@ -5,12 +5,14 @@ import (
 	"errors"
 	"time"
 
+	"github.com/glkeru/loyalty/health"
 	db "github.com/glkeru/loyalty/points/internal/db"
 	interf "github.com/glkeru/loyalty/points/internal/interfaces"
 	model "github.com/glkeru/loyalty/points/internal/models"
 	services "github.com/glkeru/loyalty/points/internal/services"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"go.uber.org/zap"
 )
@@ -19,9 +21,12 @@ type PointsService struct {
 	service *services.PointsService
 	UnimplementedGetPointsServer
 	logger *zap.Logger
+	hub    *services.EventHub // события движения баллов для WatchBalance
+	stop   context.CancelFunc // остановка чтения событий и подписки на изменения кэша
 }
 
-func NewPointsService(logger *zap.Logger) *PointsService {
+// checker - проверки состояния Postgres и Redis
+func NewPointsService(logger *zap.Logger, checker *health.Checker) *PointsService {
 	// database
 	var storage interf.PointsStorage
 	dt, err := db.NewPointsDB(logger)
@@ -29,59 +34,161 @@ func NewPointsService(logger *zap.Logger) *PointsService {
 		panic(err)
 	}
 	storage = dt
+	checker.Add("postgres", dt.Ping)
+
+	// фоновые подписки останавливаются в Close
+	ctx, stop := context.WithCancel(context.Background())
 
 	// cache
-	var redis interf.CacheStorage
-	redis, err = db.NewCacheService()
+	var cache interf.CacheStorage
+	redis, err := db.NewCacheService()
 	if err != nil {
 		logger.Error(err.Error())
-		redis = nil
+	} else {
+		cache = redis
+		checker.AddOptional("redis", redis.Ping)
+		// локальный кэш перед Redis, изменения балансов на других репликах приходят через pub/sub
+		local, err := db.NewLocalCache(redis)
+		if err != nil {
+			logger.Info("local cache is disabled", zap.Error(err))
+		} else {
+			cache = local
+			go func() {
+				// переподписка после ошибки, пропущенные изменения устаревают по ttl локального кэша
+				for ctx.Err() == nil {
+					err := redis.Subscribe(ctx, local.Changed)
+					if err != nil && ctx.Err() == nil {
+						logger.Error("balance changes subscription failed", zap.Error(err))
+					}
+					select {
+					case <-ctx.Done():
+					case <-time.After(time.Second):
+					}
+				}
+			}()
+		}
+	}
+	serv := services.NewPointService(logger, storage, cache)
+
+	// события движения баллов из Postgres LISTEN/NOTIFY рассылаются подписчикам WatchBalance
+	hub := services.NewEventHub()
+	go func() {
+		for ctx.Err() == nil {
+			err := dt.ListenEvents(ctx, hub.Publish)
+			if err != nil {
+				logger.Error("points events listener failed", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+	return &PointsService{
+		service: serv,
+		logger:  logger,
+		hub:     hub,
+		stop:    stop,
+	}
+}
+
+// Остановка чтения событий и подписки на изменения кэша, открытые потоки WatchBalance завершаются
+func (p *PointsService) Close() {
+	p.stop()
+	p.hub.Close()
+}
+
+// Поток изменений баланса пользователя до отключения клиента
+// события, произошедшие до подписки, не передаются: текущий баланс - GetBalance после открытия потока
+func (p *PointsService) WatchBalance(in *WatchBalanceRequest, stream GetPoints_WatchBalanceServer) error {
+	if in.User == "" {
+		return status.Error(codes.InvalidArgument, "user is required")
+	}
+	if err := checkUser(stream.Context(), in.User); err != nil {
+		return err
+	}
+	watcher := p.hub.Subscribe(in.User)
+	defer p.hub.Unsubscribe(watcher)
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				if watcher.Overflow {
+					return status.Error(codes.ResourceExhausted, "client is too slow, reconnect and call GetBalance")
+				}
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+			err := stream.Send(&BalanceEvent{
+				EventId:       event.EventId,
+				Type:          event.Type,
+				Wallet:        event.Wallet,
+				Delta:         event.Delta,
+				Balance:       event.Balance,
+				Pending:       event.Pending,
+				CorrelationId: event.CorrelationId,
+				OccurredAt:    timestamppb.New(event.OccurredAt),
+			})
+			if err != nil {
+				return err
+			}
+		}
 	}
-	serv := services.NewPointService(logger, storage, redis)
-	return &PointsService{serv, UnimplementedGetPointsServer{}, logger}
 }
 
 // Баланс
 func (p *PointsService) GetBalance(ctx context.Context, in *BalanceRequest) (*BalanceResponse, error) {
-	points, err := p.service.GetBalance(ctx, in.User)
+	if err := checkUser(ctx, in.User); err != nil {
+		return nil, err
+	}
+	balance, err := p.service.GetBalance(ctx, in.User)
 	if err != nil {
-		if errors.Is(err, model.ErrNotFound) {
-			return nil, status.Error(codes.NotFound, err.Error())
+		return nil, p.statusError(err)
+	}
+	// кошельки в фиксированном порядке
+	wallets := make([]*WalletBalance, 0, len(balance.Balances))
+	for _, wallet := range []string{model.WALLET_BASE, model.WALLET_PROMO, model.WALLET_MILES} {
+		if points, ok := balance.Balances[wallet]; ok {
+			wallets = append(wallets, &WalletBalance{Wallet: wallet, Points: points})
 		}
-		p.service.Log(err)
-		return nil, err
 	}
 	return &BalanceResponse{
-		Points: points,
+		Points:  balance.Balances[model.WALLET_BASE],
+		Tier:    balance.Tier,
+		Wallets: wallets,
 	}, nil
 }
 
 // История транзакций
 func (p *PointsService) GetTnx(ctx context.Context, in *TnxRequest) (*TnxResponse, error) {
+	if err := checkUser(ctx, in.User); err != nil {
+		return nil, err
+	}
 	user := in.User
 	from, err := time.Parse("2006-01-02 15:04:05", in.Datefrom+" 00:00:00")
 	if err != nil {
-		p.service.Log(err)
-		return nil, err
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 	to, err := time.Parse("2006-01-02 15:04:05", in.Dateto+" 23:59:59")
 	if err != nil {
-		p.service.Log(err)
-		return nil, err
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 	// получить транзакции
 	tnxs, err := p.service.GetTnx(ctx, user, from, to)
 	if err != nil {
-		if errors.Is(err, model.ErrNotFound) {
-			return nil, status.Error(codes.NotFound, err.Error())
-		}
-		p.service.Log(err)
-		return nil, err
+		return nil, p.statusError(err)
 	}
 	// сформировать ответ
 	count := len(tnxs)
 	resp := make([]*TnxMessage, count)
 	for i, v := range tnxs {
+		var expires string
+		if !v.ExpiresAt.IsZero() {
+			expires = v.ExpiresAt.Format(time.RFC3339)
+		}
 		resp[i] = &TnxMessage{
 			UUID:       v.UUID.String(),
 			Points:     v.Points,
@@ -91,7 +198,162 @@ func (p *PointsService) GetTnx(ctx context.Context, in *TnxRequest) (*TnxRespons
 			Order:      v.OrderID,
 			Transfer:   v.TransferID,
 			Redeem:     v.RedeemID,
+			Adjust:     v.AdjustID,
+			Reason:     v.Reason,
+			Wallet:     v.Wallet,
+			Expires:    expires,
 		}
 	}
 	return &TnxResponse{Tnx: resp}, nil
 }
+
+// Постраничная история транзакций с фильтрами
+func (p *PointsService) ListTransactions(ctx context.Context, in *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	if err := checkUser(ctx, in.User); err != nil {
+		return nil, err
+	}
+	filter := model.TnxFilter{
+		User:     in.User,
+		Wallet:   in.Wallet,
+		OrderID:  in.Order,
+		RedeemID: in.Redeem,
+		Limit:    int(in.PageSize),
+	}
+	if in.From != nil {
+		filter.From = in.From.AsTime()
+	}
+	if in.To != nil {
+		filter.To = in.To.AsTime()
+	}
+	for _, t := range in.Types {
+		filter.Types = append(filter.Types, int(t))
+	}
+	tnxs, next, err := p.service.ListTransactions(ctx, filter, in.PageToken)
+	if err != nil {
+		return nil, p.statusError(err)
+	}
+	resp := make([]*Transaction, len(tnxs))
+	for i, v := range tnxs {
+		resp[i] = &Transaction{
+			Uuid:       v.UUID.String(),
+			Points:     v.Points,
+			CommitDate: timestamppb.New(v.CommitDate),
+			Type:       int32(v.TypeTnx),
+			Order:      v.OrderID,
+			Transfer:   v.TransferID,
+			Redeem:     v.RedeemID,
+			Adjust:     v.AdjustID,
+			Reason:     v.Reason,
+			Wallet:     v.Wallet,
+		}
+		if !v.ExpiresAt.IsZero() {
+			resp[i].ExpiresAt = timestamppb.New(v.ExpiresAt)
+		}
+	}
+	return &ListTransactionsResponse{Transactions: resp, NextPageToken: next}, nil
+}
+
+// корзина из запроса
+func basketItems(in []*BasketItem) []model.BasketItem {
+	items := make([]model.BasketItem, len(in))
+	for i, v := range in {
+		items[i] = model.BasketItem{
+			Sku:      v.Sku,
+			Category: v.Category,
+			Price:    v.Price,
+			Quantity: v.Quantity,
+		}
+	}
+	return items
+}
+
+// Максимальное списание баллов для корзины
+func (p *PointsService) QuoteRedeem(ctx context.Context, in *QuoteRedeemRequest) (*QuoteRedeemResponse, error) {
+	if err := checkUser(ctx, in.User); err != nil {
+		return nil, err
+	}
+	quote, err := p.service.QuoteRedeem(ctx, in.User, in.Wallet, basketItems(in.Items))
+	if err != nil {
+		return nil, p.statusError(err)
+	}
+	return &QuoteRedeemResponse{
+		Points:   quote.Points,
+		Amount:   quote.Amount,
+		Eligible: quote.Eligible,
+		Balance:  quote.Balance,
+		Rate:     quote.Rate,
+	}, nil
+}
+
+// Резервирование баллов
+func (p *PointsService) HoldPoints(ctx context.Context, in *HoldRequest) (*HoldResponse, error) {
+	expires, err := p.service.Hold(ctx, in.User, in.Wallet, in.Points, in.Redeem, time.Duration(in.Ttl)*time.Second, basketItems(in.Items))
+	if err != nil {
+		return nil, p.statusError(err)
+	}
+	return &HoldResponse{
+		Redeem:  in.Redeem,
+		Expires: expires.Format(time.RFC3339),
+	}, nil
+}
+
+// Подтверждение резерва
+func (p *PointsService) CaptureHold(ctx context.Context, in *HoldActionRequest) (*HoldActionResponse, error) {
+	err := p.service.CaptureHold(ctx, in.Redeem)
+	if err != nil {
+		return nil, p.statusError(err)
+	}
+	return &HoldActionResponse{Redeem: in.Redeem}, nil
+}
+
+// Снятие резерва
+func (p *PointsService) ReleaseHold(ctx context.Context, in *HoldActionRequest) (*HoldActionResponse, error) {
+	err := p.service.ReleaseHold(ctx, in.Redeem)
+	if err != nil {
+		return nil, p.statusError(err)
+	}
+	return &HoldActionResponse{Redeem: in.Redeem}, nil
+}
+
+// Списание
+func (p *PointsService) Redeem(ctx context.Context, in *RedeemRequest) (*RedeemResponse, error) {
+	err := p.service.TnxRedeemCreate(ctx, in.User, in.Wallet, in.Points, in.Redeem, basketItems(in.Items))
+	if err != nil {
+		return nil, p.statusError(err)
+	}
+	return &RedeemResponse{Redeem: in.Redeem}, nil
+}
+
+// Перевод баллов
+func (p *PointsService) Transfer(ctx context.Context, in *TransferRequest) (*TransferResponse, error) {
+	err := p.service.Transfer(ctx, in.Userfrom, in.Userto, in.Wallet, in.Points, in.Transfer)
+	if err != nil {
+		return nil, p.statusError(err)
+	}
+	return &TransferResponse{Transfer: in.Transfer}, nil
+}
+
+// Ручная корректировка баланса
+func (p *PointsService) Adjust(ctx context.Context, in *AdjustRequest) (*AdjustResponse, error) {
+	err := p.service.Adjust(ctx, in.User, in.Wallet, in.Points, in.Adjust, in.Reason)
+	if err != nil {
+		return nil, p.statusError(err)
+	}
+	return &AdjustResponse{Adjust: in.Adjust}, nil
+}
+
+// преобразование ошибок сервиса в статусы gRPC
+func (p *PointsService) statusError(err error) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, model.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, model.ErrNotEnoughPoints), errors.Is(err, model.ErrHoldNotActive):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	p.service.Log(err)
+	return status.Error(codes.Internal, err.Error())
+}
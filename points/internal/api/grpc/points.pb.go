@@ -7,8 +7,10 @@
 package grpc
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -69,7 +71,9 @@ func (x *BalanceRequest) GetUser() string {
 // Баланс - ответ
 type BalanceResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Points        float64                `protobuf:"fixed64,1,opt,name=points,proto3" json:"points,omitempty"` // кол-во баллов
+	Points        float64                `protobuf:"fixed64,1,opt,name=points,proto3" json:"points,omitempty"` // кол-во баллов основного кошелька
+	Tier          string                 `protobuf:"bytes,2,opt,name=tier,proto3" json:"tier,omitempty"`       // уровень статуса
+	Wallets       []*WalletBalance       `protobuf:"bytes,3,rep,name=wallets,proto3" json:"wallets,omitempty"` // балансы кошельков
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -111,6 +115,219 @@ func (x *BalanceResponse) GetPoints() float64 {
 	return 0
 }
 
+func (x *BalanceResponse) GetTier() string {
+	if x != nil {
+		return x.Tier
+	}
+	return ""
+}
+
+func (x *BalanceResponse) GetWallets() []*WalletBalance {
+	if x != nil {
+		return x.Wallets
+	}
+	return nil
+}
+
+// Баланс кошелька
+type WalletBalance struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallet        string                 `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`   // кошелек: BASE, PROMO, MILES
+	Points        float64                `protobuf:"fixed64,2,opt,name=points,proto3" json:"points,omitempty"` // доступное кол-во баллов
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletBalance) Reset() {
+	*x = WalletBalance{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletBalance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletBalance) ProtoMessage() {}
+
+func (x *WalletBalance) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletBalance.ProtoReflect.Descriptor instead.
+func (*WalletBalance) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WalletBalance) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *WalletBalance) GetPoints() float64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+// Подписка на изменения баланса - запрос
+type WatchBalanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"` // ID пользователя
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchBalanceRequest) Reset() {
+	*x = WatchBalanceRequest{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchBalanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchBalanceRequest) ProtoMessage() {}
+
+func (x *WatchBalanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchBalanceRequest.ProtoReflect.Descriptor instead.
+func (*WatchBalanceRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WatchBalanceRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+// Изменение баланса кошелька
+type BalanceEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`                   // ID события
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`                                        // тип: commit, redeem, transfer, adjust, expiry, accrual, return, ...
+	Wallet        string                 `protobuf:"bytes,3,opt,name=wallet,proto3" json:"wallet,omitempty"`                                    // кошелек
+	Delta         float64                `protobuf:"fixed64,4,opt,name=delta,proto3" json:"delta,omitempty"`                                    // изменение баланса
+	Balance       float64                `protobuf:"fixed64,5,opt,name=balance,proto3" json:"balance,omitempty"`                                // баланс кошелька после изменения, без учета резервов
+	Pending       float64                `protobuf:"fixed64,6,opt,name=pending,proto3" json:"pending,omitempty"`                                // изменение баллов, ожидающих зачисления (accrual, return)
+	CorrelationId string                 `protobuf:"bytes,7,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"` // ID заказа, списания, перевода, корректировки
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`          // дата/время события
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BalanceEvent) Reset() {
+	*x = BalanceEvent{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BalanceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BalanceEvent) ProtoMessage() {}
+
+func (x *BalanceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BalanceEvent.ProtoReflect.Descriptor instead.
+func (*BalanceEvent) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BalanceEvent) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *BalanceEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *BalanceEvent) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *BalanceEvent) GetDelta() float64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *BalanceEvent) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *BalanceEvent) GetPending() float64 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
+}
+
+func (x *BalanceEvent) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *BalanceEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
 // Транзакции - запрос
 type TnxRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -123,7 +340,7 @@ type TnxRequest struct {
 
 func (x *TnxRequest) Reset() {
 	*x = TnxRequest{}
-	mi := &file_internal_api_grpc_points_proto_msgTypes[2]
+	mi := &file_internal_api_grpc_points_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -135,7 +352,7 @@ func (x *TnxRequest) String() string {
 func (*TnxRequest) ProtoMessage() {}
 
 func (x *TnxRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_grpc_points_proto_msgTypes[2]
+	mi := &file_internal_api_grpc_points_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -148,7 +365,7 @@ func (x *TnxRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TnxRequest.ProtoReflect.Descriptor instead.
 func (*TnxRequest) Descriptor() ([]byte, []int) {
-	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{2}
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *TnxRequest) GetUser() string {
@@ -182,7 +399,7 @@ type TnxResponse struct {
 
 func (x *TnxResponse) Reset() {
 	*x = TnxResponse{}
-	mi := &file_internal_api_grpc_points_proto_msgTypes[3]
+	mi := &file_internal_api_grpc_points_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -194,7 +411,7 @@ func (x *TnxResponse) String() string {
 func (*TnxResponse) ProtoMessage() {}
 
 func (x *TnxResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_grpc_points_proto_msgTypes[3]
+	mi := &file_internal_api_grpc_points_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -207,7 +424,7 @@ func (x *TnxResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TnxResponse.ProtoReflect.Descriptor instead.
 func (*TnxResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{3}
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *TnxResponse) GetTnx() []*TnxMessage {
@@ -227,13 +444,17 @@ type TnxMessage struct {
 	Order         string                 `protobuf:"bytes,6,opt,name=order,proto3" json:"order,omitempty"`           // ID заказа
 	Transfer      string                 `protobuf:"bytes,7,opt,name=transfer,proto3" json:"transfer,omitempty"`     // ID операции перевода баллов
 	Redeem        string                 `protobuf:"bytes,8,opt,name=redeem,proto3" json:"redeem,omitempty"`         // ID операции списания баллов
+	Adjust        string                 `protobuf:"bytes,9,opt,name=adjust,proto3" json:"adjust,omitempty"`         // ID операции корректировки
+	Reason        string                 `protobuf:"bytes,10,opt,name=reason,proto3" json:"reason,omitempty"`        // код причины корректировки
+	Wallet        string                 `protobuf:"bytes,11,opt,name=wallet,proto3" json:"wallet,omitempty"`        // кошелек
+	Expires       string                 `protobuf:"bytes,12,opt,name=expires,proto3" json:"expires,omitempty"`      // дата/время сгорания начисленных баллов, RFC3339, пустая - баллы не сгорают
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TnxMessage) Reset() {
 	*x = TnxMessage{}
-	mi := &file_internal_api_grpc_points_proto_msgTypes[4]
+	mi := &file_internal_api_grpc_points_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -245,7 +466,7 @@ func (x *TnxMessage) String() string {
 func (*TnxMessage) ProtoMessage() {}
 
 func (x *TnxMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_grpc_points_proto_msgTypes[4]
+	mi := &file_internal_api_grpc_points_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -258,7 +479,7 @@ func (x *TnxMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TnxMessage.ProtoReflect.Descriptor instead.
 func (*TnxMessage) Descriptor() ([]byte, []int) {
-	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{4}
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *TnxMessage) GetUUID() string {
@@ -317,38 +538,1269 @@ func (x *TnxMessage) GetRedeem() string {
 	return ""
 }
 
-var File_internal_api_grpc_points_proto protoreflect.FileDescriptor
+func (x *TnxMessage) GetAdjust() string {
+	if x != nil {
+		return x.Adjust
+	}
+	return ""
+}
 
-const file_internal_api_grpc_points_proto_rawDesc = "" +
-	"\n" +
-	"\x1einternal/api/grpc/points.proto\x12\x06points\"$\n" +
-	"\x0eBalanceRequest\x12\x12\n" +
-	"\x04user\x18\x01 \x01(\tR\x04user\")\n" +
-	"\x0fBalanceResponse\x12\x16\n" +
-	"\x06points\x18\x01 \x01(\x01R\x06points\"T\n" +
-	"\n" +
-	"TnxRequest\x12\x12\n" +
-	"\x04user\x18\x01 \x01(\tR\x04user\x12\x1a\n" +
-	"\bdatefrom\x18\x02 \x01(\tR\bdatefrom\x12\x16\n" +
-	"\x06dateto\x18\x03 \x01(\tR\x06dateto\"3\n" +
-	"\vTnxResponse\x12$\n" +
-	"\x03Tnx\x18\x01 \x03(\v2\x12.points.TnxMessageR\x03Tnx\"\xd4\x01\n" +
-	"\n" +
-	"TnxMessage\x12\x12\n" +
-	"\x04UUID\x18\x01 \x01(\tR\x04UUID\x12\x16\n" +
-	"\x06points\x18\x02 \x01(\x01R\x06points\x12\x1e\n" +
-	"\n" +
-	"CommitDate\x18\x03 \x01(\tR\n" +
-	"CommitDate\x12\x16\n" +
-	"\x06Commit\x18\x04 \x01(\bR\x06Commit\x12\x18\n" +
-	"\aTypeTnx\x18\x05 \x01(\x05R\aTypeTnx\x12\x14\n" +
-	"\x05order\x18\x06 \x01(\tR\x05order\x12\x1a\n" +
-	"\btransfer\x18\a \x01(\tR\btransfer\x12\x16\n" +
-	"\x06redeem\x18\b \x01(\tR\x06redeem2\x81\x01\n" +
-	"\tGetPoints\x12?\n" +
+func (x *TnxMessage) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *TnxMessage) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *TnxMessage) GetExpires() string {
+	if x != nil {
+		return x.Expires
+	}
+	return ""
+}
+
+// История транзакций - запрос
+// транзакции сортируются по дате и UUID в обратном порядке, пустые фильтры не ограничивают выборку
+type ListTransactionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`                            // ID пользователя
+	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`                            // дата транзакции с
+	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`                                // дата транзакции по
+	Types         []int32                `protobuf:"varint,4,rep,packed,name=types,proto3" json:"types,omitempty"`                  // типы операций
+	Wallet        string                 `protobuf:"bytes,5,opt,name=wallet,proto3" json:"wallet,omitempty"`                        // кошелек
+	Order         string                 `protobuf:"bytes,6,opt,name=order,proto3" json:"order,omitempty"`                          // ID заказа
+	Redeem        string                 `protobuf:"bytes,7,opt,name=redeem,proto3" json:"redeem,omitempty"`                        // ID операции списания баллов
+	PageSize      int32                  `protobuf:"varint,8,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // кол-во транзакций на странице, 0 - 50, не больше 500
+	PageToken     string                 `protobuf:"bytes,9,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token предыдущей страницы, пустой - первая страница
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsRequest) Reset() {
+	*x = ListTransactionsRequest{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsRequest) ProtoMessage() {}
+
+func (x *ListTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ListTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListTransactionsRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ListTransactionsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ListTransactionsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *ListTransactionsRequest) GetTypes() []int32 {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *ListTransactionsRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *ListTransactionsRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+func (x *ListTransactionsRequest) GetRedeem() string {
+	if x != nil {
+		return x.Redeem
+	}
+	return ""
+}
+
+func (x *ListTransactionsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListTransactionsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// История транзакций - ответ
+type ListTransactionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // курсор следующей страницы, пустой - страница последняя
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsResponse) Reset() {
+	*x = ListTransactionsResponse{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsResponse) ProtoMessage() {}
+
+func (x *ListTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*ListTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListTransactionsResponse) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *ListTransactionsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`                               // UUID транзакции
+	Points        float64                `protobuf:"fixed64,2,opt,name=points,proto3" json:"points,omitempty"`                         // кол-во баллов
+	CommitDate    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=commit_date,json=commitDate,proto3" json:"commit_date,omitempty"` // дата/время транзакции
+	Type          int32                  `protobuf:"varint,4,opt,name=type,proto3" json:"type,omitempty"`                              // тип операции
+	Order         string                 `protobuf:"bytes,5,opt,name=order,proto3" json:"order,omitempty"`                             // ID заказа
+	Transfer      string                 `protobuf:"bytes,6,opt,name=transfer,proto3" json:"transfer,omitempty"`                       // ID операции перевода баллов
+	Redeem        string                 `protobuf:"bytes,7,opt,name=redeem,proto3" json:"redeem,omitempty"`                           // ID операции списания баллов
+	Adjust        string                 `protobuf:"bytes,8,opt,name=adjust,proto3" json:"adjust,omitempty"`                           // ID операции корректировки
+	Reason        string                 `protobuf:"bytes,9,opt,name=reason,proto3" json:"reason,omitempty"`                           // код причины корректировки
+	Wallet        string                 `protobuf:"bytes,10,opt,name=wallet,proto3" json:"wallet,omitempty"`                          // кошелек
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`   // дата/время сгорания начисленных баллов, пустая - баллы не сгорают
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Transaction) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Transaction) GetPoints() float64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *Transaction) GetCommitDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CommitDate
+	}
+	return nil
+}
+
+func (x *Transaction) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Transaction) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+func (x *Transaction) GetTransfer() string {
+	if x != nil {
+		return x.Transfer
+	}
+	return ""
+}
+
+func (x *Transaction) GetRedeem() string {
+	if x != nil {
+		return x.Redeem
+	}
+	return ""
+}
+
+func (x *Transaction) GetAdjust() string {
+	if x != nil {
+		return x.Adjust
+	}
+	return ""
+}
+
+func (x *Transaction) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Transaction) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *Transaction) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+// Списание - запрос
+type RedeemRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`       // ID пользователя
+	Points        float64                `protobuf:"fixed64,2,opt,name=points,proto3" json:"points,omitempty"` // кол-во баллов
+	Redeem        string                 `protobuf:"bytes,3,opt,name=redeem,proto3" json:"redeem,omitempty"`   // ID операции списания баллов
+	Wallet        string                 `protobuf:"bytes,4,opt,name=wallet,proto3" json:"wallet,omitempty"`   // кошелек, по умолчанию BASE
+	Items         []*BasketItem          `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`     // корзина, оплачиваемая баллами
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedeemRequest) Reset() {
+	*x = RedeemRequest{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedeemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedeemRequest) ProtoMessage() {}
+
+func (x *RedeemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedeemRequest.ProtoReflect.Descriptor instead.
+func (*RedeemRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RedeemRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *RedeemRequest) GetPoints() float64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *RedeemRequest) GetRedeem() string {
+	if x != nil {
+		return x.Redeem
+	}
+	return ""
+}
+
+func (x *RedeemRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *RedeemRequest) GetItems() []*BasketItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// Списание - ответ
+type RedeemResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Redeem        string                 `protobuf:"bytes,1,opt,name=redeem,proto3" json:"redeem,omitempty"` // ID операции списания баллов
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedeemResponse) Reset() {
+	*x = RedeemResponse{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedeemResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedeemResponse) ProtoMessage() {}
+
+func (x *RedeemResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedeemResponse.ProtoReflect.Descriptor instead.
+func (*RedeemResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RedeemResponse) GetRedeem() string {
+	if x != nil {
+		return x.Redeem
+	}
+	return ""
+}
+
+// Перевод баллов - запрос
+type TransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Userfrom      string                 `protobuf:"bytes,1,opt,name=userfrom,proto3" json:"userfrom,omitempty"` // ID пользователя - отправителя
+	Userto        string                 `protobuf:"bytes,2,opt,name=userto,proto3" json:"userto,omitempty"`     // ID пользователя - получателя
+	Points        float64                `protobuf:"fixed64,3,opt,name=points,proto3" json:"points,omitempty"`   // кол-во баллов
+	Transfer      string                 `protobuf:"bytes,4,opt,name=transfer,proto3" json:"transfer,omitempty"` // ID операции перевода баллов
+	Wallet        string                 `protobuf:"bytes,5,opt,name=wallet,proto3" json:"wallet,omitempty"`     // кошелек, по умолчанию BASE
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferRequest) Reset() {
+	*x = TransferRequest{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferRequest) ProtoMessage() {}
+
+func (x *TransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferRequest.ProtoReflect.Descriptor instead.
+func (*TransferRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TransferRequest) GetUserfrom() string {
+	if x != nil {
+		return x.Userfrom
+	}
+	return ""
+}
+
+func (x *TransferRequest) GetUserto() string {
+	if x != nil {
+		return x.Userto
+	}
+	return ""
+}
+
+func (x *TransferRequest) GetPoints() float64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *TransferRequest) GetTransfer() string {
+	if x != nil {
+		return x.Transfer
+	}
+	return ""
+}
+
+func (x *TransferRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+// Перевод баллов - ответ
+type TransferResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transfer      string                 `protobuf:"bytes,1,opt,name=transfer,proto3" json:"transfer,omitempty"` // ID операции перевода баллов
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferResponse) Reset() {
+	*x = TransferResponse{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferResponse) ProtoMessage() {}
+
+func (x *TransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferResponse.ProtoReflect.Descriptor instead.
+func (*TransferResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TransferResponse) GetTransfer() string {
+	if x != nil {
+		return x.Transfer
+	}
+	return ""
+}
+
+// Корректировка баланса - запрос
+type AdjustRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`       // ID пользователя
+	Points        float64                `protobuf:"fixed64,2,opt,name=points,proto3" json:"points,omitempty"` // кол-во баллов: > 0 - начисление, < 0 - списание
+	Adjust        string                 `protobuf:"bytes,3,opt,name=adjust,proto3" json:"adjust,omitempty"`   // ID операции корректировки
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`   // код причины: GOODWILL, CORRECTION, FRAUD, MIGRATION
+	Wallet        string                 `protobuf:"bytes,5,opt,name=wallet,proto3" json:"wallet,omitempty"`   // кошелек, по умолчанию BASE
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdjustRequest) Reset() {
+	*x = AdjustRequest{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdjustRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdjustRequest) ProtoMessage() {}
+
+func (x *AdjustRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdjustRequest.ProtoReflect.Descriptor instead.
+func (*AdjustRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *AdjustRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *AdjustRequest) GetPoints() float64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *AdjustRequest) GetAdjust() string {
+	if x != nil {
+		return x.Adjust
+	}
+	return ""
+}
+
+func (x *AdjustRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *AdjustRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+// Корректировка баланса - ответ
+type AdjustResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Adjust        string                 `protobuf:"bytes,1,opt,name=adjust,proto3" json:"adjust,omitempty"` // ID операции корректировки
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdjustResponse) Reset() {
+	*x = AdjustResponse{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdjustResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdjustResponse) ProtoMessage() {}
+
+func (x *AdjustResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdjustResponse.ProtoReflect.Descriptor instead.
+func (*AdjustResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AdjustResponse) GetAdjust() string {
+	if x != nil {
+		return x.Adjust
+	}
+	return ""
+}
+
+// Резервирование баллов - запрос
+type HoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`       // ID пользователя
+	Points        float64                `protobuf:"fixed64,2,opt,name=points,proto3" json:"points,omitempty"` // кол-во баллов
+	Redeem        string                 `protobuf:"bytes,3,opt,name=redeem,proto3" json:"redeem,omitempty"`   // ID операции списания баллов
+	Ttl           int64                  `protobuf:"varint,4,opt,name=ttl,proto3" json:"ttl,omitempty"`        // время жизни резерва, сек (0 - по умолчанию)
+	Wallet        string                 `protobuf:"bytes,5,opt,name=wallet,proto3" json:"wallet,omitempty"`   // кошелек, по умолчанию BASE
+	Items         []*BasketItem          `protobuf:"bytes,6,rep,name=items,proto3" json:"items,omitempty"`     // корзина, оплачиваемая баллами
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldRequest) Reset() {
+	*x = HoldRequest{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldRequest) ProtoMessage() {}
+
+func (x *HoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldRequest.ProtoReflect.Descriptor instead.
+func (*HoldRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *HoldRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *HoldRequest) GetPoints() float64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *HoldRequest) GetRedeem() string {
+	if x != nil {
+		return x.Redeem
+	}
+	return ""
+}
+
+func (x *HoldRequest) GetTtl() int64 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+func (x *HoldRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *HoldRequest) GetItems() []*BasketItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// Резервирование баллов - ответ
+type HoldResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Redeem        string                 `protobuf:"bytes,1,opt,name=redeem,proto3" json:"redeem,omitempty"`   // ID операции списания баллов
+	Expires       string                 `protobuf:"bytes,2,opt,name=expires,proto3" json:"expires,omitempty"` // дата/время снятия резерва, RFC3339
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldResponse) Reset() {
+	*x = HoldResponse{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldResponse) ProtoMessage() {}
+
+func (x *HoldResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldResponse.ProtoReflect.Descriptor instead.
+func (*HoldResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *HoldResponse) GetRedeem() string {
+	if x != nil {
+		return x.Redeem
+	}
+	return ""
+}
+
+func (x *HoldResponse) GetExpires() string {
+	if x != nil {
+		return x.Expires
+	}
+	return ""
+}
+
+// Подтверждение/снятие резерва - запрос
+type HoldActionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Redeem        string                 `protobuf:"bytes,1,opt,name=redeem,proto3" json:"redeem,omitempty"` // ID операции списания баллов
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldActionRequest) Reset() {
+	*x = HoldActionRequest{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldActionRequest) ProtoMessage() {}
+
+func (x *HoldActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldActionRequest.ProtoReflect.Descriptor instead.
+func (*HoldActionRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HoldActionRequest) GetRedeem() string {
+	if x != nil {
+		return x.Redeem
+	}
+	return ""
+}
+
+// Подтверждение/снятие резерва - ответ
+type HoldActionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Redeem        string                 `protobuf:"bytes,1,opt,name=redeem,proto3" json:"redeem,omitempty"` // ID операции списания баллов
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldActionResponse) Reset() {
+	*x = HoldActionResponse{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldActionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldActionResponse) ProtoMessage() {}
+
+func (x *HoldActionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldActionResponse.ProtoReflect.Descriptor instead.
+func (*HoldActionResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *HoldActionResponse) GetRedeem() string {
+	if x != nil {
+		return x.Redeem
+	}
+	return ""
+}
+
+// Позиция корзины
+type BasketItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`             // ID товара
+	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`   // категория товара
+	Price         float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`       // цена за единицу
+	Quantity      float64                `protobuf:"fixed64,4,opt,name=quantity,proto3" json:"quantity,omitempty"` // кол-во (0 - одна единица)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BasketItem) Reset() {
+	*x = BasketItem{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BasketItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BasketItem) ProtoMessage() {}
+
+func (x *BasketItem) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BasketItem.ProtoReflect.Descriptor instead.
+func (*BasketItem) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BasketItem) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *BasketItem) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *BasketItem) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *BasketItem) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+// Расчет списания - запрос
+type QuoteRedeemRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`     // ID пользователя
+	Wallet        string                 `protobuf:"bytes,2,opt,name=wallet,proto3" json:"wallet,omitempty"` // кошелек, по умолчанию BASE
+	Items         []*BasketItem          `protobuf:"bytes,3,rep,name=items,proto3" json:"items,omitempty"`   // корзина
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuoteRedeemRequest) Reset() {
+	*x = QuoteRedeemRequest{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuoteRedeemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuoteRedeemRequest) ProtoMessage() {}
+
+func (x *QuoteRedeemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuoteRedeemRequest.ProtoReflect.Descriptor instead.
+func (*QuoteRedeemRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *QuoteRedeemRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *QuoteRedeemRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *QuoteRedeemRequest) GetItems() []*BasketItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// Расчет списания - ответ
+type QuoteRedeemResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Points        float64                `protobuf:"fixed64,1,opt,name=points,proto3" json:"points,omitempty"`     // максимальное кол-во баллов для оплаты корзины
+	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`     // сумма оплаты баллами
+	Eligible      float64                `protobuf:"fixed64,3,opt,name=eligible,proto3" json:"eligible,omitempty"` // сумма позиций, которые можно оплатить баллами
+	Balance       float64                `protobuf:"fixed64,4,opt,name=balance,proto3" json:"balance,omitempty"`   // доступный баланс кошелька
+	Rate          float64                `protobuf:"fixed64,5,opt,name=rate,proto3" json:"rate,omitempty"`         // стоимость одного балла
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuoteRedeemResponse) Reset() {
+	*x = QuoteRedeemResponse{}
+	mi := &file_internal_api_grpc_points_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuoteRedeemResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuoteRedeemResponse) ProtoMessage() {}
+
+func (x *QuoteRedeemResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_grpc_points_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuoteRedeemResponse.ProtoReflect.Descriptor instead.
+func (*QuoteRedeemResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_grpc_points_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *QuoteRedeemResponse) GetPoints() float64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *QuoteRedeemResponse) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *QuoteRedeemResponse) GetEligible() float64 {
+	if x != nil {
+		return x.Eligible
+	}
+	return 0
+}
+
+func (x *QuoteRedeemResponse) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *QuoteRedeemResponse) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+var File_internal_api_grpc_points_proto protoreflect.FileDescriptor
+
+const file_internal_api_grpc_points_proto_rawDesc = "" +
+	"\n" +
+	"\x1einternal/api/grpc/points.proto\x12\x06points\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"$\n" +
+	"\x0eBalanceRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"n\n" +
+	"\x0fBalanceResponse\x12\x16\n" +
+	"\x06points\x18\x01 \x01(\x01R\x06points\x12\x12\n" +
+	"\x04tier\x18\x02 \x01(\tR\x04tier\x12/\n" +
+	"\awallets\x18\x03 \x03(\v2\x15.points.WalletBalanceR\awallets\"?\n" +
+	"\rWalletBalance\x12\x16\n" +
+	"\x06wallet\x18\x01 \x01(\tR\x06wallet\x12\x16\n" +
+	"\x06points\x18\x02 \x01(\x01R\x06points\")\n" +
+	"\x13WatchBalanceRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"\x83\x02\n" +
+	"\fBalanceEvent\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06wallet\x18\x03 \x01(\tR\x06wallet\x12\x14\n" +
+	"\x05delta\x18\x04 \x01(\x01R\x05delta\x12\x18\n" +
+	"\abalance\x18\x05 \x01(\x01R\abalance\x12\x18\n" +
+	"\apending\x18\x06 \x01(\x01R\apending\x12%\n" +
+	"\x0ecorrelation_id\x18\a \x01(\tR\rcorrelationId\x12;\n" +
+	"\voccurred_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"T\n" +
+	"\n" +
+	"TnxRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x1a\n" +
+	"\bdatefrom\x18\x02 \x01(\tR\bdatefrom\x12\x16\n" +
+	"\x06dateto\x18\x03 \x01(\tR\x06dateto\"3\n" +
+	"\vTnxResponse\x12$\n" +
+	"\x03Tnx\x18\x01 \x03(\v2\x12.points.TnxMessageR\x03Tnx\"\xb6\x02\n" +
+	"\n" +
+	"TnxMessage\x12\x12\n" +
+	"\x04UUID\x18\x01 \x01(\tR\x04UUID\x12\x16\n" +
+	"\x06points\x18\x02 \x01(\x01R\x06points\x12\x1e\n" +
+	"\n" +
+	"CommitDate\x18\x03 \x01(\tR\n" +
+	"CommitDate\x12\x16\n" +
+	"\x06Commit\x18\x04 \x01(\bR\x06Commit\x12\x18\n" +
+	"\aTypeTnx\x18\x05 \x01(\x05R\aTypeTnx\x12\x14\n" +
+	"\x05order\x18\x06 \x01(\tR\x05order\x12\x1a\n" +
+	"\btransfer\x18\a \x01(\tR\btransfer\x12\x16\n" +
+	"\x06redeem\x18\b \x01(\tR\x06redeem\x12\x16\n" +
+	"\x06adjust\x18\t \x01(\tR\x06adjust\x12\x16\n" +
+	"\x06reason\x18\n" +
+	" \x01(\tR\x06reason\x12\x16\n" +
+	"\x06wallet\x18\v \x01(\tR\x06wallet\x12\x18\n" +
+	"\aexpires\x18\f \x01(\tR\aexpires\"\xa1\x02\n" +
+	"\x17ListTransactionsRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
+	"\x05types\x18\x04 \x03(\x05R\x05types\x12\x16\n" +
+	"\x06wallet\x18\x05 \x01(\tR\x06wallet\x12\x14\n" +
+	"\x05order\x18\x06 \x01(\tR\x05order\x12\x16\n" +
+	"\x06redeem\x18\a \x01(\tR\x06redeem\x12\x1b\n" +
+	"\tpage_size\x18\b \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\t \x01(\tR\tpageToken\"{\n" +
+	"\x18ListTransactionsResponse\x127\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x13.points.TransactionR\ftransactions\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xd7\x02\n" +
+	"\vTransaction\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12\x16\n" +
+	"\x06points\x18\x02 \x01(\x01R\x06points\x12;\n" +
+	"\vcommit_date\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"commitDate\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\x05R\x04type\x12\x14\n" +
+	"\x05order\x18\x05 \x01(\tR\x05order\x12\x1a\n" +
+	"\btransfer\x18\x06 \x01(\tR\btransfer\x12\x16\n" +
+	"\x06redeem\x18\a \x01(\tR\x06redeem\x12\x16\n" +
+	"\x06adjust\x18\b \x01(\tR\x06adjust\x12\x16\n" +
+	"\x06reason\x18\t \x01(\tR\x06reason\x12\x16\n" +
+	"\x06wallet\x18\n" +
+	" \x01(\tR\x06wallet\x129\n" +
+	"\n" +
+	"expires_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\x95\x01\n" +
+	"\rRedeemRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06points\x18\x02 \x01(\x01R\x06points\x12\x16\n" +
+	"\x06redeem\x18\x03 \x01(\tR\x06redeem\x12\x16\n" +
+	"\x06wallet\x18\x04 \x01(\tR\x06wallet\x12(\n" +
+	"\x05items\x18\x05 \x03(\v2\x12.points.BasketItemR\x05items\"(\n" +
+	"\x0eRedeemResponse\x12\x16\n" +
+	"\x06redeem\x18\x01 \x01(\tR\x06redeem\"\x91\x01\n" +
+	"\x0fTransferRequest\x12\x1a\n" +
+	"\buserfrom\x18\x01 \x01(\tR\buserfrom\x12\x16\n" +
+	"\x06userto\x18\x02 \x01(\tR\x06userto\x12\x16\n" +
+	"\x06points\x18\x03 \x01(\x01R\x06points\x12\x1a\n" +
+	"\btransfer\x18\x04 \x01(\tR\btransfer\x12\x16\n" +
+	"\x06wallet\x18\x05 \x01(\tR\x06wallet\".\n" +
+	"\x10TransferResponse\x12\x1a\n" +
+	"\btransfer\x18\x01 \x01(\tR\btransfer\"\x83\x01\n" +
+	"\rAdjustRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06points\x18\x02 \x01(\x01R\x06points\x12\x16\n" +
+	"\x06adjust\x18\x03 \x01(\tR\x06adjust\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x16\n" +
+	"\x06wallet\x18\x05 \x01(\tR\x06wallet\"(\n" +
+	"\x0eAdjustResponse\x12\x16\n" +
+	"\x06adjust\x18\x01 \x01(\tR\x06adjust\"\xa5\x01\n" +
+	"\vHoldRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06points\x18\x02 \x01(\x01R\x06points\x12\x16\n" +
+	"\x06redeem\x18\x03 \x01(\tR\x06redeem\x12\x10\n" +
+	"\x03ttl\x18\x04 \x01(\x03R\x03ttl\x12\x16\n" +
+	"\x06wallet\x18\x05 \x01(\tR\x06wallet\x12(\n" +
+	"\x05items\x18\x06 \x03(\v2\x12.points.BasketItemR\x05items\"@\n" +
+	"\fHoldResponse\x12\x16\n" +
+	"\x06redeem\x18\x01 \x01(\tR\x06redeem\x12\x18\n" +
+	"\aexpires\x18\x02 \x01(\tR\aexpires\"+\n" +
+	"\x11HoldActionRequest\x12\x16\n" +
+	"\x06redeem\x18\x01 \x01(\tR\x06redeem\",\n" +
+	"\x12HoldActionResponse\x12\x16\n" +
+	"\x06redeem\x18\x01 \x01(\tR\x06redeem\"l\n" +
+	"\n" +
+	"BasketItem\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x01R\bquantity\"j\n" +
+	"\x12QuoteRedeemRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06wallet\x18\x02 \x01(\tR\x06wallet\x12(\n" +
+	"\x05items\x18\x03 \x03(\v2\x12.points.BasketItemR\x05items\"\x8f\x01\n" +
+	"\x13QuoteRedeemResponse\x12\x16\n" +
+	"\x06points\x18\x01 \x01(\x01R\x06points\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\beligible\x18\x03 \x01(\x01R\beligible\x12\x18\n" +
+	"\abalance\x18\x04 \x01(\x01R\abalance\x12\x12\n" +
+	"\x04rate\x18\x05 \x01(\x01R\x04rate2\xfb\b\n" +
+	"\tGetPoints\x12_\n" +
+	"\n" +
+	"GetBalance\x12\x16.points.BalanceRequest\x1a\x17.points.BalanceResponse\" \x82\xd3\xe4\x93\x02\x1a\x12\x18/v1/users/{user}/balance\x12k\n" +
+	"\fWatchBalance\x12\x1b.points.WatchBalanceRequest\x1a\x14.points.BalanceEvent\"&\x82\xd3\xe4\x93\x02 \x12\x1e/v1/users/{user}/balance:watch0\x01\x12O\n" +
+	"\x06GetTnx\x12\x12.points.TnxRequest\x1a\x13.points.TnxResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/v1/users/{user}/tnx\x12|\n" +
+	"\x10ListTransactions\x12\x1f.points.ListTransactionsRequest\x1a .points.ListTransactionsResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/v1/users/{user}/transactions\x12q\n" +
+	"\vQuoteRedeem\x12\x1a.points.QuoteRedeemRequest\x1a\x1b.points.QuoteRedeemResponse\")\x82\xd3\xe4\x93\x02#:\x01*\"\x1e/v1/users/{user}/redeems:quote\x12Z\n" +
 	"\n" +
-	"GetBalance\x12\x16.points.BalanceRequest\x1a\x17.points.BalanceResponse\"\x00\x123\n" +
-	"\x06GetTnx\x12\x12.points.TnxRequest\x1a\x13.points.TnxResponse\"\x00B9Z7github.com/glkeru/loyalty/points/internal/api/grpc;grpcb\x06proto3"
+	"HoldPoints\x12\x13.points.HoldRequest\x1a\x14.points.HoldResponse\"!\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/v1/users/{user}/holds\x12k\n" +
+	"\vCaptureHold\x12\x19.points.HoldActionRequest\x1a\x1a.points.HoldActionResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/v1/holds/{redeem}:capture\x12k\n" +
+	"\vReleaseHold\x12\x19.points.HoldActionRequest\x1a\x1a.points.HoldActionResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/v1/holds/{redeem}:release\x12\\\n" +
+	"\x06Redeem\x12\x15.points.RedeemRequest\x1a\x16.points.RedeemResponse\"#\x82\xd3\xe4\x93\x02\x1d:\x01*\"\x18/v1/users/{user}/redeems\x12h\n" +
+	"\bTransfer\x12\x17.points.TransferRequest\x1a\x18.points.TransferResponse\")\x82\xd3\xe4\x93\x02#:\x01*\"\x1e/v1/users/{userfrom}/transfers\x12`\n" +
+	"\x06Adjust\x12\x15.points.AdjustRequest\x1a\x16.points.AdjustResponse\"'\x82\xd3\xe4\x93\x02!:\x01*\"\x1c/v1/users/{user}/adjustmentsB9Z7github.com/glkeru/loyalty/points/internal/api/grpc;grpcb\x06proto3"
 
 var (
 	file_internal_api_grpc_points_proto_rawDescOnce sync.Once
@@ -362,25 +1814,73 @@ func file_internal_api_grpc_points_proto_rawDescGZIP() []byte {
 	return file_internal_api_grpc_points_proto_rawDescData
 }
 
-var file_internal_api_grpc_points_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_internal_api_grpc_points_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_internal_api_grpc_points_proto_goTypes = []any{
-	(*BalanceRequest)(nil),  // 0: points.BalanceRequest
-	(*BalanceResponse)(nil), // 1: points.BalanceResponse
-	(*TnxRequest)(nil),      // 2: points.TnxRequest
-	(*TnxResponse)(nil),     // 3: points.TnxResponse
-	(*TnxMessage)(nil),      // 4: points.TnxMessage
+	(*BalanceRequest)(nil),           // 0: points.BalanceRequest
+	(*BalanceResponse)(nil),          // 1: points.BalanceResponse
+	(*WalletBalance)(nil),            // 2: points.WalletBalance
+	(*WatchBalanceRequest)(nil),      // 3: points.WatchBalanceRequest
+	(*BalanceEvent)(nil),             // 4: points.BalanceEvent
+	(*TnxRequest)(nil),               // 5: points.TnxRequest
+	(*TnxResponse)(nil),              // 6: points.TnxResponse
+	(*TnxMessage)(nil),               // 7: points.TnxMessage
+	(*ListTransactionsRequest)(nil),  // 8: points.ListTransactionsRequest
+	(*ListTransactionsResponse)(nil), // 9: points.ListTransactionsResponse
+	(*Transaction)(nil),              // 10: points.Transaction
+	(*RedeemRequest)(nil),            // 11: points.RedeemRequest
+	(*RedeemResponse)(nil),           // 12: points.RedeemResponse
+	(*TransferRequest)(nil),          // 13: points.TransferRequest
+	(*TransferResponse)(nil),         // 14: points.TransferResponse
+	(*AdjustRequest)(nil),            // 15: points.AdjustRequest
+	(*AdjustResponse)(nil),           // 16: points.AdjustResponse
+	(*HoldRequest)(nil),              // 17: points.HoldRequest
+	(*HoldResponse)(nil),             // 18: points.HoldResponse
+	(*HoldActionRequest)(nil),        // 19: points.HoldActionRequest
+	(*HoldActionResponse)(nil),       // 20: points.HoldActionResponse
+	(*BasketItem)(nil),               // 21: points.BasketItem
+	(*QuoteRedeemRequest)(nil),       // 22: points.QuoteRedeemRequest
+	(*QuoteRedeemResponse)(nil),      // 23: points.QuoteRedeemResponse
+	(*timestamppb.Timestamp)(nil),    // 24: google.protobuf.Timestamp
 }
 var file_internal_api_grpc_points_proto_depIdxs = []int32{
-	4, // 0: points.TnxResponse.Tnx:type_name -> points.TnxMessage
-	0, // 1: points.GetPoints.GetBalance:input_type -> points.BalanceRequest
-	2, // 2: points.GetPoints.GetTnx:input_type -> points.TnxRequest
-	1, // 3: points.GetPoints.GetBalance:output_type -> points.BalanceResponse
-	3, // 4: points.GetPoints.GetTnx:output_type -> points.TnxResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	2,  // 0: points.BalanceResponse.wallets:type_name -> points.WalletBalance
+	24, // 1: points.BalanceEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	7,  // 2: points.TnxResponse.Tnx:type_name -> points.TnxMessage
+	24, // 3: points.ListTransactionsRequest.from:type_name -> google.protobuf.Timestamp
+	24, // 4: points.ListTransactionsRequest.to:type_name -> google.protobuf.Timestamp
+	10, // 5: points.ListTransactionsResponse.transactions:type_name -> points.Transaction
+	24, // 6: points.Transaction.commit_date:type_name -> google.protobuf.Timestamp
+	24, // 7: points.Transaction.expires_at:type_name -> google.protobuf.Timestamp
+	21, // 8: points.RedeemRequest.items:type_name -> points.BasketItem
+	21, // 9: points.HoldRequest.items:type_name -> points.BasketItem
+	21, // 10: points.QuoteRedeemRequest.items:type_name -> points.BasketItem
+	0,  // 11: points.GetPoints.GetBalance:input_type -> points.BalanceRequest
+	3,  // 12: points.GetPoints.WatchBalance:input_type -> points.WatchBalanceRequest
+	5,  // 13: points.GetPoints.GetTnx:input_type -> points.TnxRequest
+	8,  // 14: points.GetPoints.ListTransactions:input_type -> points.ListTransactionsRequest
+	22, // 15: points.GetPoints.QuoteRedeem:input_type -> points.QuoteRedeemRequest
+	17, // 16: points.GetPoints.HoldPoints:input_type -> points.HoldRequest
+	19, // 17: points.GetPoints.CaptureHold:input_type -> points.HoldActionRequest
+	19, // 18: points.GetPoints.ReleaseHold:input_type -> points.HoldActionRequest
+	11, // 19: points.GetPoints.Redeem:input_type -> points.RedeemRequest
+	13, // 20: points.GetPoints.Transfer:input_type -> points.TransferRequest
+	15, // 21: points.GetPoints.Adjust:input_type -> points.AdjustRequest
+	1,  // 22: points.GetPoints.GetBalance:output_type -> points.BalanceResponse
+	4,  // 23: points.GetPoints.WatchBalance:output_type -> points.BalanceEvent
+	6,  // 24: points.GetPoints.GetTnx:output_type -> points.TnxResponse
+	9,  // 25: points.GetPoints.ListTransactions:output_type -> points.ListTransactionsResponse
+	23, // 26: points.GetPoints.QuoteRedeem:output_type -> points.QuoteRedeemResponse
+	18, // 27: points.GetPoints.HoldPoints:output_type -> points.HoldResponse
+	20, // 28: points.GetPoints.CaptureHold:output_type -> points.HoldActionResponse
+	20, // 29: points.GetPoints.ReleaseHold:output_type -> points.HoldActionResponse
+	12, // 30: points.GetPoints.Redeem:output_type -> points.RedeemResponse
+	14, // 31: points.GetPoints.Transfer:output_type -> points.TransferResponse
+	16, // 32: points.GetPoints.Adjust:output_type -> points.AdjustResponse
+	22, // [22:33] is the sub-list for method output_type
+	11, // [11:22] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_internal_api_grpc_points_proto_init() }
@@ -394,7 +1894,7 @@ func file_internal_api_grpc_points_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_api_grpc_points_proto_rawDesc), len(file_internal_api_grpc_points_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
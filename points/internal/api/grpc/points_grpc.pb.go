@@ -19,18 +19,37 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GetPoints_GetBalance_FullMethodName = "/points.GetPoints/GetBalance"
-	GetPoints_GetTnx_FullMethodName     = "/points.GetPoints/GetTnx"
+	GetPoints_GetBalance_FullMethodName       = "/points.GetPoints/GetBalance"
+	GetPoints_WatchBalance_FullMethodName     = "/points.GetPoints/WatchBalance"
+	GetPoints_GetTnx_FullMethodName           = "/points.GetPoints/GetTnx"
+	GetPoints_ListTransactions_FullMethodName = "/points.GetPoints/ListTransactions"
+	GetPoints_QuoteRedeem_FullMethodName      = "/points.GetPoints/QuoteRedeem"
+	GetPoints_HoldPoints_FullMethodName       = "/points.GetPoints/HoldPoints"
+	GetPoints_CaptureHold_FullMethodName      = "/points.GetPoints/CaptureHold"
+	GetPoints_ReleaseHold_FullMethodName      = "/points.GetPoints/ReleaseHold"
+	GetPoints_Redeem_FullMethodName           = "/points.GetPoints/Redeem"
+	GetPoints_Transfer_FullMethodName         = "/points.GetPoints/Transfer"
+	GetPoints_Adjust_FullMethodName           = "/points.GetPoints/Adjust"
 )
 
 // GetPointsClient is the client API for GetPoints service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// сервис: получение баланса, получение транзакций
+// сервис: получение баланса и подписка на его изменения, получение транзакций и постраничной истории, расчет, резервирование, списание, перевод и корректировка баллов
+// HTTP/JSON: шлюз gRPC-Gateway по аннотациям google.api.http
 type GetPointsClient interface {
 	GetBalance(ctx context.Context, in *BalanceRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
+	WatchBalance(ctx context.Context, in *WatchBalanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BalanceEvent], error)
 	GetTnx(ctx context.Context, in *TnxRequest, opts ...grpc.CallOption) (*TnxResponse, error)
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	QuoteRedeem(ctx context.Context, in *QuoteRedeemRequest, opts ...grpc.CallOption) (*QuoteRedeemResponse, error)
+	HoldPoints(ctx context.Context, in *HoldRequest, opts ...grpc.CallOption) (*HoldResponse, error)
+	CaptureHold(ctx context.Context, in *HoldActionRequest, opts ...grpc.CallOption) (*HoldActionResponse, error)
+	ReleaseHold(ctx context.Context, in *HoldActionRequest, opts ...grpc.CallOption) (*HoldActionResponse, error)
+	Redeem(ctx context.Context, in *RedeemRequest, opts ...grpc.CallOption) (*RedeemResponse, error)
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	Adjust(ctx context.Context, in *AdjustRequest, opts ...grpc.CallOption) (*AdjustResponse, error)
 }
 
 type getPointsClient struct {
@@ -51,6 +70,25 @@ func (c *getPointsClient) GetBalance(ctx context.Context, in *BalanceRequest, op
 	return out, nil
 }
 
+func (c *getPointsClient) WatchBalance(ctx context.Context, in *WatchBalanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BalanceEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GetPoints_ServiceDesc.Streams[0], GetPoints_WatchBalance_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchBalanceRequest, BalanceEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GetPoints_WatchBalanceClient = grpc.ServerStreamingClient[BalanceEvent]
+
 func (c *getPointsClient) GetTnx(ctx context.Context, in *TnxRequest, opts ...grpc.CallOption) (*TnxResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(TnxResponse)
@@ -61,14 +99,104 @@ func (c *getPointsClient) GetTnx(ctx context.Context, in *TnxRequest, opts ...gr
 	return out, nil
 }
 
+func (c *getPointsClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, GetPoints_ListTransactions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getPointsClient) QuoteRedeem(ctx context.Context, in *QuoteRedeemRequest, opts ...grpc.CallOption) (*QuoteRedeemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuoteRedeemResponse)
+	err := c.cc.Invoke(ctx, GetPoints_QuoteRedeem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getPointsClient) HoldPoints(ctx context.Context, in *HoldRequest, opts ...grpc.CallOption) (*HoldResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HoldResponse)
+	err := c.cc.Invoke(ctx, GetPoints_HoldPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getPointsClient) CaptureHold(ctx context.Context, in *HoldActionRequest, opts ...grpc.CallOption) (*HoldActionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HoldActionResponse)
+	err := c.cc.Invoke(ctx, GetPoints_CaptureHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getPointsClient) ReleaseHold(ctx context.Context, in *HoldActionRequest, opts ...grpc.CallOption) (*HoldActionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HoldActionResponse)
+	err := c.cc.Invoke(ctx, GetPoints_ReleaseHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getPointsClient) Redeem(ctx context.Context, in *RedeemRequest, opts ...grpc.CallOption) (*RedeemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RedeemResponse)
+	err := c.cc.Invoke(ctx, GetPoints_Redeem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getPointsClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferResponse)
+	err := c.cc.Invoke(ctx, GetPoints_Transfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getPointsClient) Adjust(ctx context.Context, in *AdjustRequest, opts ...grpc.CallOption) (*AdjustResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdjustResponse)
+	err := c.cc.Invoke(ctx, GetPoints_Adjust_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GetPointsServer is the server API for GetPoints service.
 // All implementations must embed UnimplementedGetPointsServer
 // for forward compatibility.
 //
-// сервис: получение баланса, получение транзакций
+// сервис: получение баланса и подписка на его изменения, получение транзакций и постраничной истории, расчет, резервирование, списание, перевод и корректировка баллов
+// HTTP/JSON: шлюз gRPC-Gateway по аннотациям google.api.http
 type GetPointsServer interface {
 	GetBalance(context.Context, *BalanceRequest) (*BalanceResponse, error)
+	WatchBalance(*WatchBalanceRequest, grpc.ServerStreamingServer[BalanceEvent]) error
 	GetTnx(context.Context, *TnxRequest) (*TnxResponse, error)
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	QuoteRedeem(context.Context, *QuoteRedeemRequest) (*QuoteRedeemResponse, error)
+	HoldPoints(context.Context, *HoldRequest) (*HoldResponse, error)
+	CaptureHold(context.Context, *HoldActionRequest) (*HoldActionResponse, error)
+	ReleaseHold(context.Context, *HoldActionRequest) (*HoldActionResponse, error)
+	Redeem(context.Context, *RedeemRequest) (*RedeemResponse, error)
+	Transfer(context.Context, *TransferRequest) (*TransferResponse, error)
+	Adjust(context.Context, *AdjustRequest) (*AdjustResponse, error)
 	mustEmbedUnimplementedGetPointsServer()
 }
 
@@ -82,9 +210,36 @@ type UnimplementedGetPointsServer struct{}
 func (UnimplementedGetPointsServer) GetBalance(context.Context, *BalanceRequest) (*BalanceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
 }
+func (UnimplementedGetPointsServer) WatchBalance(*WatchBalanceRequest, grpc.ServerStreamingServer[BalanceEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBalance not implemented")
+}
 func (UnimplementedGetPointsServer) GetTnx(context.Context, *TnxRequest) (*TnxResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTnx not implemented")
 }
+func (UnimplementedGetPointsServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedGetPointsServer) QuoteRedeem(context.Context, *QuoteRedeemRequest) (*QuoteRedeemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuoteRedeem not implemented")
+}
+func (UnimplementedGetPointsServer) HoldPoints(context.Context, *HoldRequest) (*HoldResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HoldPoints not implemented")
+}
+func (UnimplementedGetPointsServer) CaptureHold(context.Context, *HoldActionRequest) (*HoldActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CaptureHold not implemented")
+}
+func (UnimplementedGetPointsServer) ReleaseHold(context.Context, *HoldActionRequest) (*HoldActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseHold not implemented")
+}
+func (UnimplementedGetPointsServer) Redeem(context.Context, *RedeemRequest) (*RedeemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Redeem not implemented")
+}
+func (UnimplementedGetPointsServer) Transfer(context.Context, *TransferRequest) (*TransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedGetPointsServer) Adjust(context.Context, *AdjustRequest) (*AdjustResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Adjust not implemented")
+}
 func (UnimplementedGetPointsServer) mustEmbedUnimplementedGetPointsServer() {}
 func (UnimplementedGetPointsServer) testEmbeddedByValue()                   {}
 
@@ -124,6 +279,17 @@ func _GetPoints_GetBalance_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GetPoints_WatchBalance_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchBalanceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GetPointsServer).WatchBalance(m, &grpc.GenericServerStream[WatchBalanceRequest, BalanceEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GetPoints_WatchBalanceServer = grpc.ServerStreamingServer[BalanceEvent]
+
 func _GetPoints_GetTnx_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(TnxRequest)
 	if err := dec(in); err != nil {
@@ -142,6 +308,150 @@ func _GetPoints_GetTnx_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GetPoints_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetPointsServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetPoints_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetPointsServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetPoints_QuoteRedeem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuoteRedeemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetPointsServer).QuoteRedeem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetPoints_QuoteRedeem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetPointsServer).QuoteRedeem(ctx, req.(*QuoteRedeemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetPoints_HoldPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetPointsServer).HoldPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetPoints_HoldPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetPointsServer).HoldPoints(ctx, req.(*HoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetPoints_CaptureHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HoldActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetPointsServer).CaptureHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetPoints_CaptureHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetPointsServer).CaptureHold(ctx, req.(*HoldActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetPoints_ReleaseHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HoldActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetPointsServer).ReleaseHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetPoints_ReleaseHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetPointsServer).ReleaseHold(ctx, req.(*HoldActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetPoints_Redeem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedeemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetPointsServer).Redeem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetPoints_Redeem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetPointsServer).Redeem(ctx, req.(*RedeemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetPoints_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetPointsServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetPoints_Transfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetPointsServer).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetPoints_Adjust_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdjustRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetPointsServer).Adjust(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetPoints_Adjust_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetPointsServer).Adjust(ctx, req.(*AdjustRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // GetPoints_ServiceDesc is the grpc.ServiceDesc for GetPoints service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -157,7 +467,45 @@ var GetPoints_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTnx",
 			Handler:    _GetPoints_GetTnx_Handler,
 		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _GetPoints_ListTransactions_Handler,
+		},
+		{
+			MethodName: "QuoteRedeem",
+			Handler:    _GetPoints_QuoteRedeem_Handler,
+		},
+		{
+			MethodName: "HoldPoints",
+			Handler:    _GetPoints_HoldPoints_Handler,
+		},
+		{
+			MethodName: "CaptureHold",
+			Handler:    _GetPoints_CaptureHold_Handler,
+		},
+		{
+			MethodName: "ReleaseHold",
+			Handler:    _GetPoints_ReleaseHold_Handler,
+		},
+		{
+			MethodName: "Redeem",
+			Handler:    _GetPoints_Redeem_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _GetPoints_Transfer_Handler,
+		},
+		{
+			MethodName: "Adjust",
+			Handler:    _GetPoints_Adjust_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchBalance",
+			Handler:       _GetPoints_WatchBalance_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/api/grpc/points.proto",
 }
@@ -2,16 +2,47 @@ package points
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"time"
 
+	model "github.com/glkeru/loyalty/points/internal/models"
 	redis "github.com/redis/go-redis/v9"
 )
 
+// служебные поля hash баланса, кошельки хранятся в полях с кодом кошелька
+const (
+	fieldVersion  = "_version"
+	fieldNotFound = "_notfound"
+	fieldTier     = "_tier"
+)
+
+// запись баланса, если сохраненная версия отсутствует или старше
+// KEYS[1] - ключ, ARGV[1] - версия, ARGV[2] - ttl в мс, далее пары поле/значение
+var setBalanceScript = redis.NewScript(`
+local cur = redis.call('HGET', KEYS[1], '_version')
+if cur and tonumber(cur) >= tonumber(ARGV[1]) then
+  return 0
+end
+redis.call('DEL', KEYS[1])
+redis.call('HSET', KEYS[1], '_version', ARGV[1], unpack(ARGV, 3))
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// сообщение об изменении баланса для локальных кэшей реплик, Version = 0 - баланс удален
+type balanceChanged struct {
+	User    string `json:"user"`
+	Version int64  `json:"version"`
+}
+
 type CacheService struct {
-	client *redis.Client
+	client      *redis.Client
+	prefix      string        // пространство имен ключей
+	ttl         time.Duration // время жизни баланса
+	negativeTtl time.Duration // время жизни записи о неизвестном пользователе
 }
 
 func NewCacheService() (serv *CacheService, err error) {
@@ -22,8 +53,31 @@ func NewCacheService() (serv *CacheService, err error) {
 		return nil, fmt.Errorf("env POINTS_CACHE_URL is not set")
 	}
 	port := os.Getenv("POINTS_CACHE_PORT")
-	if addr == "" {
-		return nil, fmt.Errorf("env POINTS_CACHE_URL is not set")
+	if port == "" {
+		return nil, fmt.Errorf("env POINTS_CACHE_PORT is not set")
+	}
+	// TODO DEFAULT
+	prefix := os.Getenv("POINTS_CACHE_PREFIX")
+	if prefix == "" {
+		prefix = "points"
+	}
+	// TODO DEFAULT
+	ttl := 300
+	ttlenv := os.Getenv("POINTS_CACHE_TTL")
+	if ttlenv != "" {
+		t, err := strconv.Atoi(ttlenv)
+		if err == nil && t > 0 {
+			ttl = t
+		}
+	}
+	// TODO DEFAULT
+	negativeTtl := 30
+	negativeenv := os.Getenv("POINTS_CACHE_NEGATIVE_TTL")
+	if negativeenv != "" {
+		t, err := strconv.Atoi(negativeenv)
+		if err == nil && t > 0 {
+			negativeTtl = t
+		}
 	}
 
 	addr = addr + ":" + port
@@ -41,37 +95,127 @@ func NewCacheService() (serv *CacheService, err error) {
 		return nil, err
 	}
 
-	return &CacheService{db}, nil
+	return &CacheService{
+		client:      db,
+		prefix:      prefix,
+		ttl:         time.Duration(ttl) * time.Second,
+		negativeTtl: time.Duration(negativeTtl) * time.Second,
+	}, nil
+}
+
+// проверка соединения с Redis
+func (c *CacheService) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// ключ баланса пользователя: <prefix>:balance:<user>
+func (c *CacheService) key(user string) string {
+	return c.prefix + ":balance:" + user
+}
+
+// канал сообщений об изменении балансов: <prefix>:balance:changed
+func (c *CacheService) channel() string {
+	return c.prefix + ":balance:changed"
+}
+
+// балансы кошельков пользователя хранятся в hash: кошелек -> баланс, версия, уровень и признак неизвестного пользователя - в служебных полях
+func (c *CacheService) GetBalance(ctx context.Context, user string) (balance model.BalanceVersion, err error) {
+	val, err := c.client.HGetAll(ctx, c.key(user)).Result()
+	if err != nil {
+		return balance, err
+	}
+	if len(val) == 0 {
+		return balance, fmt.Errorf("balance cache %w", model.ErrNotFound)
+	}
+
+	balance.Balances = make(map[string]float64, len(val))
+	for field, v := range val {
+		switch field {
+		case fieldVersion:
+			balance.Version, err = strconv.ParseInt(v, 10, 64)
+		case fieldNotFound:
+			balance.NotFound = true
+		case fieldTier:
+			balance.Tier = v
+		default:
+			balance.Balances[field], err = strconv.ParseFloat(v, 64)
+		}
+		if err != nil {
+			return model.BalanceVersion{}, err
+		}
+	}
+	return balance, nil
 }
 
-func (c *CacheService) GetBalance(ctx context.Context, user string) (points float64, err error) {
-	val, err := c.client.Get(ctx, user).Result()
-	if err == redis.Nil {
-		return 0, fmt.Errorf("not found")
-	} else if err != nil {
-		return 0, err
+// запись с проверкой версии: устаревшее чтение из БД не перезаписывает более новый баланс
+func (c *CacheService) SetBalance(ctx context.Context, user string, balance model.BalanceVersion) (stored bool, err error) {
+	ttl := c.ttl
+	args := []any{balance.Version, 0}
+	if balance.NotFound {
+		ttl = c.negativeTtl
+		args = append(args, fieldNotFound, 1)
+	}
+	if balance.Tier != "" {
+		args = append(args, fieldTier, balance.Tier)
+	}
+	args[1] = ttl.Milliseconds()
+	for wallet, points := range balance.Balances {
+		args = append(args, wallet, points)
 	}
 
-	points, err = strconv.ParseFloat(val, 64)
+	res, err := setBalanceScript.Run(ctx, c.client, []string{c.key(user)}, args...).Int()
 	if err != nil {
-		return 0, err
+		return false, err
+	}
+	if res == 0 {
+		return false, nil
+	}
+	return true, c.publish(ctx, user, balance.Version)
+}
 
+func (c *CacheService) InvalidateBalance(ctx context.Context, user string) error {
+	err := c.client.Del(ctx, c.key(user)).Err()
+	if err != nil {
+		return err
 	}
-	return points, nil
+	return c.publish(ctx, user, 0)
 }
 
-func (c *CacheService) SetBalance(ctx context.Context, user string, points float64) (err error) {
-	err = c.client.Set(ctx, user, points, 5*time.Minute).Err()
+// уведомление реплик об изменении баланса
+func (c *CacheService) publish(ctx context.Context, user string, version int64) error {
+	msg, err := json.Marshal(balanceChanged{user, version})
 	if err != nil {
 		return err
 	}
-	return nil
+	return c.client.Publish(ctx, c.channel(), msg).Err()
 }
 
-func (c *CacheService) InvalidateBalance(ctx context.Context, user string) error {
-	err := c.client.Del(ctx, user).Err()
+// подписка на изменения балансов до отмены ctx
+// сообщения, пропущенные при переподключении, не восстанавливаются: устаревание ограничено ttl локального кэша
+func (c *CacheService) Subscribe(ctx context.Context, handler func(user string, version int64)) error {
+	sub := c.client.Subscribe(ctx, c.channel())
+	defer sub.Close()
+	// ожидание подтверждения подписки
+	_, err := sub.Receive(ctx)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			var changed balanceChanged
+			err = json.Unmarshal([]byte(msg.Payload), &changed)
+			if err != nil {
+				continue
+			}
+			handler(changed.User, changed.Version)
+		}
+	}
 }
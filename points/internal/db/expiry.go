@@ -0,0 +1,141 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Списания и сгорания уменьшают сначала самые ранние начисления, поэтому к сгоранию на дату:
+// не возвращенные начисления ($3) со сроком до даты минус все списания и переводы ($4), прошлые сгорания ($5)
+// и отрицательные корректировки ($6)
+const expiringSQL = `COALESCE(SUM(t.points) FILTER (WHERE t.typetnx = $3 AND NOT t.returned AND t.expiresat <= $2), 0)
+	- COALESCE(SUM(t.points) FILTER (WHERE t.typetnx IN ($4, $5)), 0)
+	+ COALESCE(SUM(t.points) FILTER (WHERE t.typetnx = $6 AND t.points < 0), 0)`
+
+// Сгорание баллов кошелька со сроком действия, возвращает пользователей, у которых изменился баланс
+func (p *PointsDB) ExpirePoints(ctx context.Context, wallet string, date time.Time) (users []string, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// счета, по которым есть начисления с наступившим сроком
+	sql, args, err := sq.Select("pointaccount").
+		Distinct().
+		From("tnx").
+		Where(sq.Eq{"wallet": wallet}).
+		Where(sq.Eq{"commit": true}).
+		Where(sq.Eq{"typetnx": model.ACCRUEL}).
+		Where(sq.Eq{"returned": false}).
+		Where(sq.LtOrEq{"expiresat": date}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+	var accounts []uuid.UUID
+	for rows.Next() {
+		var account uuid.UUID
+		err = rows.Scan(&account)
+		if err != nil {
+			rows.Close()
+			conn.Release()
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	rows.Close()
+	conn.Release()
+
+	// каждый счет обрабатывается в своей транзакции
+	for _, account := range accounts {
+		user, points, err := p.expireAccount(ctx, account, date)
+		if err != nil {
+			p.logger.Error("Expire points error",
+				zap.Error(err),
+				zap.String("service", "ExpirePoints"),
+				zap.String("account", account.String()))
+			continue
+		}
+		if points > 0 {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// сгорание баллов одного счета, зарезервированные баллы не сгорают до снятия резерва
+func (p *PointsDB) expireAccount(ctx context.Context, account uuid.UUID, date time.Time) (user string, points float64, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	var wallet string
+	var balance, hold float64
+	row := tx.QueryRow(ctx, "SELECT userid, wallet, balance, hold from ACCOUNTS where uuid = $1 FOR UPDATE", account)
+	err = row.Scan(&user, &wallet, &balance, &hold)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", 0, fmt.Errorf("account %w", model.ErrNotFound)
+		}
+		return "", 0, err
+	}
+	row = tx.QueryRow(ctx, "SELECT "+expiringSQL+" FROM tnx t WHERE t.pointaccount = $1 AND t.commit",
+		account, date, model.ACCRUEL, model.REDEEM, model.EXPIRY, model.ADJUST)
+	err = row.Scan(&points)
+	if err != nil {
+		return "", 0, err
+	}
+	points = math.Round(min(points, balance-hold)*100) / 100
+	if points <= 0 {
+		return user, 0, tx.Commit(ctx)
+	}
+
+	// проводка: сгорание баллов
+	reference := "expiry:" + account.String() + ":" + date.Format(time.RFC3339)
+	err = p.postEntry(ctx, tx, model.NewJournalEntry(model.ENTRY_EXPIRY, reference, account, model.LEDGER_EXPIRY, points))
+	if err != nil {
+		return "", 0, err
+	}
+
+	sql, args, err := sq.Insert("tnx").
+		Columns("id", "pointaccount", "wallet", "points", "commitdate", "typetnx", "commit").
+		Values(uuid.New(), account, wallet, points, date, model.EXPIRY, true).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return "", 0, err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return "", 0, err
+	}
+	return user, points, tx.Commit(ctx)
+}
@@ -0,0 +1,320 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// Холд - резервирование баллов кошелька до подтверждения списания
+func (p *PointsDB) Hold(ctx context.Context, user string, wallet string, points float64, redeemId string, expires time.Time, events ...model.OutboxMessage) (err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// проверить и заблокировать баланс
+	var currentb, hold float64
+	var pguuid pgtype.UUID
+	row := tx.QueryRow(ctx, "SELECT uuid, balance, hold from ACCOUNTS where userid = $1 AND wallet = $2 FOR UPDATE", user, wallet)
+	err = row.Scan(&pguuid, &currentb, &hold)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user wallet %s %w", wallet, model.ErrNotFound)
+		}
+		return err
+	}
+	account, _ := uuid.FromBytes(pguuid.Bytes[:])
+	if currentb-hold < points {
+		return model.ErrNotEnoughPoints
+	}
+
+	// добавить холд
+	sql, args, err := sq.Insert("holds").
+		Columns("id", "pointaccount", "points", "redeemid", "expiresat", "status").
+		Values(uuid.New(), account, points, redeemId, expires, model.HOLD_ACTIVE).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("hold %s %w", redeemId, model.ErrAlreadyExists)
+		}
+		return err
+	}
+
+	// зарезервировать баллы на счете
+	sql, args, err = sq.Update("accounts").
+		Set("hold", sq.Expr("hold + ?", points)).
+		Where(sq.Eq{"uuid": account}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	err = p.enqueue(ctx, tx, events...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Подтверждение холда - окончательное списание зарезервированных баллов
+func (p *PointsDB) CaptureHold(ctx context.Context, redeemId string, events ...model.OutboxMessage) (hold model.PointHold, user string, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	hold, user, err = p.lockHold(ctx, tx, redeemId, model.HOLD_CAPTURED)
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+	if hold.ExpiresAt.Before(time.Now()) {
+		return model.PointHold{}, "", fmt.Errorf("hold %s is expired: %w", redeemId, model.ErrHoldNotActive)
+	}
+
+	// проводка: погашение зарезервированных баллов
+	err = p.postEntry(ctx, tx, model.NewJournalEntry(model.ENTRY_REDEEM, redeemId, hold.PointAccount, model.LEDGER_REDEMPTION, hold.Points))
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+
+	// снять резерв
+	sql, args, err := sq.Update("accounts").
+		Set("hold", sq.Expr("hold - ?", hold.Points)).
+		Where(sq.Eq{"uuid": hold.PointAccount}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+
+	// добавить транзакцию списания
+	sql, args, err = sq.Insert("tnx").
+		Columns("id", "pointaccount", "wallet", "points", "commitdate", "typetnx", "redeemid", "commit").
+		Values(uuid.New(), hold.PointAccount, hold.Wallet, hold.Points, time.Now(), model.REDEEM, redeemId, true).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		// списание с тем же redeemId уже выполнено без резерва
+		if isUniqueViolation(err) {
+			return model.PointHold{}, "", fmt.Errorf("redeem %s %w", redeemId, model.ErrAlreadyExists)
+		}
+		return model.PointHold{}, "", err
+	}
+
+	err = p.setHoldStatus(ctx, tx, hold.UUID, model.HOLD_CAPTURED)
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+
+	err = p.enqueue(ctx, tx, events...)
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.PointHold{}, "", err
+	}
+	return hold, user, nil
+}
+
+// Снятие холда - возврат зарезервированных баллов в доступный баланс
+func (p *PointsDB) ReleaseHold(ctx context.Context, redeemId string, events ...model.OutboxMessage) (user string, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	hold, user, err := p.lockHold(ctx, tx, redeemId, model.HOLD_RELEASED)
+	if err != nil {
+		return "", err
+	}
+
+	// снять резерв
+	sql, args, err := sq.Update("accounts").
+		Set("hold", sq.Expr("hold - ?", hold.Points)).
+		Where(sq.Eq{"uuid": hold.PointAccount}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return "", err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return "", err
+	}
+
+	err = p.setHoldStatus(ctx, tx, hold.UUID, model.HOLD_RELEASED)
+	if err != nil {
+		return "", err
+	}
+
+	err = p.enqueue(ctx, tx, events...)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return "", err
+	}
+	return user, nil
+}
+
+// Снятие просроченных холдов, возвращает пользователей, у которых изменился баланс
+func (p *PointsDB) ReleaseExpiredHolds(ctx context.Context, date time.Time) (users []string, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args, err := sq.Select("redeemid").
+		From("holds").
+		Where(sq.Eq{"status": model.HOLD_ACTIVE}).
+		Where(sq.LtOrEq{"expiresat": date}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+	var redeems []string
+	for rows.Next() {
+		var redeemId string
+		err = rows.Scan(&redeemId)
+		if err != nil {
+			rows.Close()
+			conn.Release()
+			return nil, err
+		}
+		redeems = append(redeems, redeemId)
+	}
+	rows.Close()
+	conn.Release()
+
+	// каждый холд снимается в своей транзакции, холд мог быть подтвержден или снят параллельно
+	for _, redeemId := range redeems {
+		user, err := p.ReleaseHold(ctx, redeemId)
+		if err != nil {
+			if errors.Is(err, model.ErrHoldNotActive) || errors.Is(err, model.ErrAlreadyExists) {
+				continue
+			}
+			p.logger.Error("Release hold error",
+				zap.Error(err),
+				zap.String("service", "ReleaseExpiredHolds"),
+				zap.String("redeem", redeemId))
+			continue
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// заблокировать активный холд и счет
+// холд уже в статусе done - операция выполнена при предыдущей доставке (ErrAlreadyExists), подтверждение уже в outbox
+func (p *PointsDB) lockHold(ctx context.Context, tx pgx.Tx, redeemId string, done int) (hold model.PointHold, user string, err error) {
+	row := tx.QueryRow(ctx, `SELECT h.id, h.pointaccount, h.points, h.expiresat, h.status, a.userid, a.wallet
+		FROM holds h JOIN accounts a ON a.uuid = h.pointaccount
+		WHERE h.redeemid = $1 FOR UPDATE`, redeemId)
+	err = row.Scan(&hold.UUID, &hold.PointAccount, &hold.Points, &hold.ExpiresAt, &hold.Status, &user, &hold.Wallet)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return hold, "", fmt.Errorf("hold %s %w", redeemId, model.ErrNotFound)
+		}
+		return hold, "", err
+	}
+	if hold.Status == done {
+		return hold, "", fmt.Errorf("hold %s %w", redeemId, model.ErrAlreadyExists)
+	}
+	if hold.Status != model.HOLD_ACTIVE {
+		return hold, "", fmt.Errorf("hold %s: %w", redeemId, model.ErrHoldNotActive)
+	}
+	hold.RedeemID = redeemId
+	return hold, user, nil
+}
+
+// изменить статус холда
+func (p *PointsDB) setHoldStatus(ctx context.Context, tx pgx.Tx, hold uuid.UUID, status int) error {
+	sql, args, err := sq.Update("holds").
+		Set("status", status).
+		Where(sq.Eq{"id": hold}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	return err
+}
+
+// нарушение уникального индекса
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
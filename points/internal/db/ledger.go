@@ -0,0 +1,145 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	sq "github.com/Masterminds/squirrel"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Проводка в журнал в рамках транзакции: записывает строки и изменяет остатки счетов пользователей
+func (p *PointsDB) postEntry(ctx context.Context, tx pgx.Tx, entry model.JournalEntry) error {
+	// сумма строк проводки должна быть равна 0, считаем в копейках
+	var sum int64
+	for _, line := range entry.Lines {
+		sum += int64(math.Round(line.Amount * 100))
+	}
+	if sum != 0 || len(entry.Lines) < 2 {
+		return fmt.Errorf("journal entry %s is not balanced", entry.UUID.String())
+	}
+
+	sql, args, err := sq.Insert("journal_entries").
+		Columns("id", "entrytype", "reference", "createdat").
+		Values(entry.UUID, entry.EntryType, entry.Reference, entry.CreatedAt).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	insert := sq.Insert("journal_lines").
+		Columns("entryid", "account", "amount").
+		PlaceholderFormat(sq.Dollar)
+	for _, line := range entry.Lines {
+		insert = insert.Values(entry.UUID, line.Account, line.Amount)
+	}
+	sql, args, err = insert.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	// остатки счетов пользователей - производные от журнала, по каждому изменению - событие в outbox
+	var events []model.OutboxMessage
+	for _, line := range entry.Lines {
+		if model.IsSystemAccount(line.Account) {
+			continue
+		}
+		sql, args, err = sq.Update("accounts").
+			Set("balance", sq.Expr("balance + ?", line.Amount)).
+			Where(sq.Eq{"uuid": line.Account}).
+			Suffix("RETURNING userid, wallet, balance").
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		var user, wallet string
+		var balance float64
+		err = tx.QueryRow(ctx, sql, args...).Scan(&user, &wallet, &balance)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("account %s %w", line.Account.String(), model.ErrNotFound)
+			}
+			return err
+		}
+		event, err := model.NewPointsEvent(model.EntryEventType(entry.EntryType), user, wallet, line.Amount, balance, entry.Reference).Message()
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	return p.enqueue(ctx, tx, events...)
+}
+
+// Сверка остатков счетов пользователей с журналом проводок
+func (p *PointsDB) VerifyLedger(ctx context.Context) (drifts []model.LedgerDrift, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT a.uuid, a.userid, a.balance, COALESCE(SUM(l.amount), 0)
+		FROM accounts a LEFT JOIN journal_lines l ON l.account = a.uuid
+		GROUP BY a.uuid, a.userid, a.balance
+		HAVING a.balance <> COALESCE(SUM(l.amount), 0)
+		ORDER BY a.userid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var drift model.LedgerDrift
+		err = rows.Scan(&drift.Account, &drift.UserId, &drift.Balance, &drift.LedgerBalance)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, rows.Err()
+}
+
+// Оборотно-сальдовая ведомость: остатки системных счетов и общий остаток счетов пользователей
+func (p *PointsDB) TrialBalance(ctx context.Context) (balances []model.LedgerBalance, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT la.uuid, la.code, COALESCE(SUM(l.amount), 0)
+		FROM ledger_accounts la LEFT JOIN journal_lines l ON l.account = la.uuid
+		GROUP BY la.uuid, la.code
+		UNION ALL
+		SELECT $1::uuid, 'USERS', COALESCE(SUM(l.amount), 0)
+		FROM journal_lines l WHERE l.account NOT IN (SELECT uuid FROM ledger_accounts)
+		ORDER BY 2`, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var balance model.LedgerBalance
+		err = rows.Scan(&balance.Account, &balance.Code, &balance.Balance)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
@@ -0,0 +1,114 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// запись локального кэша; stale - баланс изменен на другой реплике, запись хранит только версию изменения
+type localBalance struct {
+	balance model.BalanceVersion
+	stale   bool
+}
+
+// Локальный кэш балансов в памяти процесса перед Redis
+// ограничен кол-вом пользователей и временем жизни, согласованность между репликами - по сообщениям Redis pub/sub
+type LocalCache struct {
+	next interf.CacheStorage
+	mu   sync.Mutex
+	lru  *expirable.LRU[string, localBalance]
+}
+
+func NewLocalCache(next interf.CacheStorage) (local *LocalCache, err error) {
+	sizeenv := os.Getenv("POINTS_LOCAL_CACHE_SIZE")
+	if sizeenv == "" {
+		return nil, fmt.Errorf("env POINTS_LOCAL_CACHE_SIZE is not set")
+	}
+	size, err := strconv.Atoi(sizeenv)
+	if err != nil || size <= 0 {
+		return nil, fmt.Errorf("env POINTS_LOCAL_CACHE_SIZE is invalid: %s", sizeenv)
+	}
+	// TODO DEFAULT
+	ttl := 1000
+	ttlenv := os.Getenv("POINTS_LOCAL_CACHE_TTL")
+	if ttlenv != "" {
+		t, err := strconv.Atoi(ttlenv)
+		if err == nil && t > 0 {
+			ttl = t
+		}
+	}
+	return newLocalCache(next, size, time.Duration(ttl)*time.Millisecond), nil
+}
+
+func newLocalCache(next interf.CacheStorage, size int, ttl time.Duration) *LocalCache {
+	return &LocalCache{
+		next: next,
+		lru:  expirable.NewLRU[string, localBalance](size, nil, ttl),
+	}
+}
+
+func (l *LocalCache) GetBalance(ctx context.Context, user string) (balance model.BalanceVersion, err error) {
+	entry, ok := l.lru.Get(user)
+	if ok && !entry.stale {
+		return entry.balance, nil
+	}
+	balance, err = l.next.GetBalance(ctx, user)
+	if err != nil {
+		return balance, err
+	}
+	l.store(user, balance)
+	return balance, nil
+}
+
+func (l *LocalCache) SetBalance(ctx context.Context, user string, balance model.BalanceVersion) (stored bool, err error) {
+	stored, err = l.next.SetBalance(ctx, user, balance)
+	if stored {
+		l.store(user, balance)
+	} else {
+		// в Redis баланс новее или запись не удалась
+		l.lru.Remove(user)
+	}
+	return stored, err
+}
+
+func (l *LocalCache) InvalidateBalance(ctx context.Context, user string) error {
+	l.lru.Remove(user)
+	return l.next.InvalidateBalance(ctx, user)
+}
+
+// обработчик сообщения об изменении баланса, version = 0 - баланс удален
+func (l *LocalCache) Changed(user string, version int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if version == 0 {
+		l.lru.Remove(user)
+		return
+	}
+	entry, ok := l.lru.Peek(user)
+	if ok && entry.balance.Version >= version {
+		return
+	}
+	// версия сохраняется, чтобы более раннее чтение из Redis не вернуло устаревший баланс
+	l.lru.Add(user, localBalance{balance: model.BalanceVersion{Version: version}, stale: true})
+}
+
+// запись баланса, если он не старше сохраненного
+func (l *LocalCache) store(user string, balance model.BalanceVersion) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.lru.Peek(user)
+	if ok && entry.balance.Version > balance.Version {
+		return
+	}
+	l.lru.Add(user, localBalance{balance: balance})
+}
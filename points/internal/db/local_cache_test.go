@@ -0,0 +1,109 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+// кэш Redis в памяти со счетчиком чтений
+type redisCache struct {
+	interf.CacheStorage
+	balances map[string]model.BalanceVersion
+	reads    int
+}
+
+func (c *redisCache) GetBalance(ctx context.Context, user string) (model.BalanceVersion, error) {
+	c.reads++
+	balance, ok := c.balances[user]
+	if !ok {
+		return balance, fmt.Errorf("balance cache %w", model.ErrNotFound)
+	}
+	return balance, nil
+}
+
+func (c *redisCache) SetBalance(ctx context.Context, user string, balance model.BalanceVersion) (bool, error) {
+	if cur, ok := c.balances[user]; ok && cur.Version >= balance.Version {
+		return false, nil
+	}
+	c.balances[user] = balance
+	return true, nil
+}
+
+func (c *redisCache) InvalidateBalance(ctx context.Context, user string) error {
+	delete(c.balances, user)
+	return nil
+}
+
+func balanceOf(points float64, version int64) model.BalanceVersion {
+	return model.BalanceVersion{Balances: map[string]float64{model.WALLET_BASE: points}, Version: version}
+}
+
+func TestLocalCache(t *testing.T) {
+	ctx := context.Background()
+	redis := &redisCache{balances: map[string]model.BalanceVersion{"u1": balanceOf(10, 1)}}
+	local := newLocalCache(redis, 10, time.Minute)
+
+	// повторное чтение - из памяти
+	balance, err := local.GetBalance(ctx, "u1")
+	require.NoError(t, err)
+	require.Equal(t, float64(10), balance.Balances[model.WALLET_BASE])
+	_, err = local.GetBalance(ctx, "u1")
+	require.NoError(t, err)
+	require.Equal(t, 1, redis.reads)
+
+	// изменение на другой реплике: запись локального кэша устаревает
+	redis.balances["u1"] = balanceOf(20, 2)
+	local.Changed("u1", 2)
+	balance, err = local.GetBalance(ctx, "u1")
+	require.NoError(t, err)
+	require.Equal(t, float64(20), balance.Balances[model.WALLET_BASE])
+	require.Equal(t, 2, redis.reads)
+
+	// собственная запись: сообщение с той же версией не удаляет баланс
+	stored, err := local.SetBalance(ctx, "u1", balanceOf(30, 3))
+	require.NoError(t, err)
+	require.True(t, stored)
+	local.Changed("u1", 3)
+	balance, err = local.GetBalance(ctx, "u1")
+	require.NoError(t, err)
+	require.Equal(t, float64(30), balance.Balances[model.WALLET_BASE])
+	require.Equal(t, 2, redis.reads)
+
+	// удаление баланса
+	err = local.InvalidateBalance(ctx, "u1")
+	require.NoError(t, err)
+	_, err = local.GetBalance(ctx, "u1")
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func TestLocalCacheStaleRead(t *testing.T) {
+	redis := &redisCache{balances: make(map[string]model.BalanceVersion)}
+	local := newLocalCache(redis, 10, time.Minute)
+
+	// сообщение о версии 5 пришло раньше, чем чтение версии 4 из Redis
+	local.Changed("u1", 5)
+	local.store("u1", balanceOf(40, 4))
+	entry, ok := local.lru.Peek("u1")
+	require.True(t, ok)
+	require.True(t, entry.stale)
+	require.Equal(t, int64(5), entry.balance.Version)
+}
+
+func TestLocalCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	redis := &redisCache{balances: map[string]model.BalanceVersion{"u1": balanceOf(10, 1)}}
+	local := newLocalCache(redis, 10, 20*time.Millisecond)
+
+	_, err := local.GetBalance(ctx, "u1")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, err := local.GetBalance(ctx, "u1")
+		return err == nil && redis.reads > 1
+	}, time.Second, 10*time.Millisecond)
+}
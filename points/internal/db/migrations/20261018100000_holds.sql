@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE accounts
+  ADD COLUMN IF NOT EXISTS hold numeric(18,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+  id           uuid PRIMARY KEY,
+  pointaccount uuid          NOT NULL,
+  points       numeric(18,2) NOT NULL,
+  redeemid     text          NOT NULL,
+  expiresat    timestamptz   NOT NULL,
+  status       int           NOT NULL DEFAULT 0,
+  CONSTRAINT holds_points_positive CHECK (points > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holds_redeem
+  ON holds (redeemid);
+
+CREATE INDEX IF NOT EXISTS idx_holds_active_expiresat
+  ON holds (expiresat) WHERE status = 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_holds_active_expiresat;
+DROP INDEX IF EXISTS idx_holds_redeem;
+DROP TABLE IF EXISTS holds;
+ALTER TABLE accounts DROP COLUMN IF EXISTS hold;
+-- +goose StatementEnd
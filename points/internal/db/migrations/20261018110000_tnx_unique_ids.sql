@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tnx
+  ADD COLUMN IF NOT EXISTS adjustid text,
+  ADD COLUMN IF NOT EXISTS reason   text;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tnx_redeem
+  ON tnx (redeemid) WHERE redeemid <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tnx_transfer
+  ON tnx (transferid, typetnx) WHERE transferid <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tnx_adjust
+  ON tnx (adjustid) WHERE adjustid <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tnx_adjust;
+DROP INDEX IF EXISTS idx_tnx_transfer;
+DROP INDEX IF EXISTS idx_tnx_redeem;
+ALTER TABLE tnx
+  DROP COLUMN IF EXISTS reason,
+  DROP COLUMN IF EXISTS adjustid;
+-- +goose StatementEnd
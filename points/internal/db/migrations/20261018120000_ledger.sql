@@ -0,0 +1,87 @@
+-- +goose Up
+-- +goose StatementBegin
+-- системные счета плана счетов
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+  uuid         uuid PRIMARY KEY,
+  code         text NOT NULL UNIQUE,
+  name         text NOT NULL
+);
+
+INSERT INTO ledger_accounts (uuid, code, name) VALUES
+  ('00000000-0000-0000-0000-000000000001', 'ISSUANCE',   'Выпуск баллов'),
+  ('00000000-0000-0000-0000-000000000002', 'REDEMPTION', 'Погашение баллов'),
+  ('00000000-0000-0000-0000-000000000003', 'BREAKAGE',   'Неиспользованные баллы'),
+  ('00000000-0000-0000-0000-000000000004', 'EXPIRY',     'Сгорание баллов'),
+  ('00000000-0000-0000-0000-000000000005', 'ADJUSTMENT', 'Ручные корректировки'),
+  ('00000000-0000-0000-0000-000000000006', 'OPENING',    'Входящие остатки')
+ON CONFLICT (uuid) DO NOTHING;
+
+-- проводки
+CREATE TABLE IF NOT EXISTS journal_entries (
+  id           uuid PRIMARY KEY,
+  entrytype    int           NOT NULL,
+  reference    text,
+  createdat    timestamptz   NOT NULL DEFAULT now()
+);
+
+-- строки проводок: amount > 0 увеличивает остаток счета, сумма строк проводки = 0
+CREATE TABLE IF NOT EXISTS journal_lines (
+  id           bigserial PRIMARY KEY,
+  entryid      uuid          NOT NULL REFERENCES journal_entries (id),
+  account      uuid          NOT NULL,
+  amount       numeric(18,2) NOT NULL,
+  CONSTRAINT journal_lines_nonzero CHECK (amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_lines_entry
+  ON journal_lines (entryid);
+
+CREATE INDEX IF NOT EXISTS idx_journal_lines_account
+  ON journal_lines (account);
+
+CREATE INDEX IF NOT EXISTS idx_journal_entries_createdat
+  ON journal_entries (createdat);
+
+-- проверка сбалансированности проводки при коммите транзакции
+CREATE OR REPLACE FUNCTION journal_entry_balanced() RETURNS trigger AS $$
+BEGIN
+  IF (SELECT SUM(amount) FROM journal_lines WHERE entryid = NEW.entryid) <> 0 THEN
+    RAISE EXCEPTION 'journal entry % is not balanced', NEW.entryid;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER journal_lines_balanced
+  AFTER INSERT OR UPDATE ON journal_lines
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION journal_entry_balanced();
+
+-- входящие остатки по существующим счетам
+CREATE TEMP TABLE opening_balances AS
+  SELECT uuid AS account, balance, gen_random_uuid() AS entryid
+  FROM accounts
+  WHERE balance <> 0;
+
+INSERT INTO journal_entries (id, entrytype, reference)
+  SELECT entryid, 0, account::text FROM opening_balances;
+
+INSERT INTO journal_lines (entryid, account, amount)
+  SELECT entryid, account, balance FROM opening_balances
+  UNION ALL
+  SELECT entryid, '00000000-0000-0000-0000-000000000006', -balance FROM opening_balances;
+
+DROP TABLE opening_balances;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS journal_lines_balanced ON journal_lines;
+DROP FUNCTION IF EXISTS journal_entry_balanced();
+DROP INDEX IF EXISTS idx_journal_entries_createdat;
+DROP INDEX IF EXISTS idx_journal_lines_account;
+DROP INDEX IF EXISTS idx_journal_lines_entry;
+DROP TABLE IF EXISTS journal_lines;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+-- +goose StatementEnd
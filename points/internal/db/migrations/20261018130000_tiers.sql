@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- уровни статуса клиента, threshold - активность за период, необходимая для уровня
+CREATE TABLE IF NOT EXISTS tiers (
+  code         text PRIMARY KEY,
+  name         text          NOT NULL,
+  threshold    numeric(18,2) NOT NULL,
+  rank         int           NOT NULL UNIQUE
+);
+
+INSERT INTO tiers (code, name, threshold, rank) VALUES
+  ('BASIC',    'Basic',    0,      0),
+  ('SILVER',   'Silver',   10000,  1),
+  ('GOLD',     'Gold',     50000,  2),
+  ('PLATINUM', 'Platinum', 150000, 3)
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE accounts
+  ADD COLUMN IF NOT EXISTS tier          text        NOT NULL DEFAULT 'BASIC',
+  ADD COLUMN IF NOT EXISTS tierchangedat timestamptz NOT NULL DEFAULT now();
+
+-- история изменения уровня
+CREATE TABLE IF NOT EXISTS tier_history (
+  id           uuid PRIMARY KEY,
+  pointaccount uuid          NOT NULL,
+  oldtier      text          NOT NULL,
+  newtier      text          NOT NULL,
+  activity     numeric(18,2) NOT NULL,
+  changedat    timestamptz   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tier_history_account
+  ON tier_history (pointaccount, changedat);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tier_history_account;
+DROP TABLE IF EXISTS tier_history;
+ALTER TABLE accounts
+  DROP COLUMN IF EXISTS tierchangedat,
+  DROP COLUMN IF EXISTS tier;
+DROP TABLE IF EXISTS tiers;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- исходящие сообщения, записываются в одной транзакции с изменением данных
+CREATE TABLE IF NOT EXISTS outbox (
+  id           bigserial PRIMARY KEY,
+  destination  text          NOT NULL,
+  topic        text          NOT NULL,
+  msgkey       text          NOT NULL DEFAULT '',
+  payload      jsonb         NOT NULL,
+  createdat    timestamptz   NOT NULL DEFAULT now(),
+  sentat       timestamptz,
+  attempts     int           NOT NULL DEFAULT 0,
+  lasterror    text
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent
+  ON outbox (id) WHERE sentat IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_unsent;
+DROP TABLE IF EXISTS outbox;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- повторная доставка заказа не создает второе начисление
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tnx_order
+  ON tnx (orderid) WHERE orderid <> '' AND typetnx = 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tnx_order;
+-- +goose StatementEnd
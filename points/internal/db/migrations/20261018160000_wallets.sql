@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- кошельки: у пользователя по счету на каждый кошелек, существующие счета - основные баллы
+ALTER TABLE accounts
+  ADD COLUMN IF NOT EXISTS wallet text NOT NULL DEFAULT 'BASE';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_user_wallet
+  ON accounts (userid, wallet);
+
+ALTER TABLE tnx
+  ADD COLUMN IF NOT EXISTS wallet    text NOT NULL DEFAULT 'BASE',
+  ADD COLUMN IF NOT EXISTS expiresat timestamptz;
+
+-- начисление по заказу - одна транзакция на кошелек
+DROP INDEX IF EXISTS idx_tnx_order;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tnx_order_wallet
+  ON tnx (orderid, wallet) WHERE orderid <> '' AND typetnx = 0;
+
+CREATE INDEX IF NOT EXISTS idx_tnx_expiresat
+  ON tnx (expiresat) WHERE expiresat IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tnx_expiresat;
+DROP INDEX IF EXISTS idx_tnx_order_wallet;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tnx_order
+  ON tnx (orderid) WHERE orderid <> '' AND typetnx = 0;
+ALTER TABLE tnx
+  DROP COLUMN IF EXISTS expiresat,
+  DROP COLUMN IF EXISTS wallet;
+DROP INDEX IF EXISTS idx_accounts_user_wallet;
+ALTER TABLE accounts DROP COLUMN IF EXISTS wallet;
+-- +goose StatementEnd
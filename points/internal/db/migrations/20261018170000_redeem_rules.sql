@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- правила списания по кошелькам: стоимость балла, доля заказа, минимальное списание, исключенные категории
+CREATE TABLE IF NOT EXISTS redeem_rules (
+  wallet       text PRIMARY KEY,
+  rate         numeric(18,4) NOT NULL,
+  maxshare     numeric(5,4)  NOT NULL,
+  minpoints    numeric(18,2) NOT NULL DEFAULT 0,
+  categories   text[]        NOT NULL DEFAULT '{}',
+  CONSTRAINT redeem_rules_rate_positive CHECK (rate > 0),
+  CONSTRAINT redeem_rules_maxshare CHECK (maxshare > 0 AND maxshare <= 1)
+);
+
+INSERT INTO redeem_rules (wallet, rate, maxshare, minpoints, categories) VALUES
+  ('BASE',  1,   0.5, 1,   '{}'),
+  ('PROMO', 1,   0.2, 1,   '{}'),
+  ('MILES', 0.5, 0.5, 100, '{}')
+ON CONFLICT (wallet) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS redeem_rules;
+-- +goose StatementEnd
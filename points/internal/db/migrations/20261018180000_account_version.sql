@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- версия счета увеличивается при каждом изменении баланса или резерва, по ней кэш отбрасывает устаревшие записи
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION accounts_version() RETURNS trigger AS $$
+BEGIN
+  NEW.version := OLD.version + 1;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER accounts_version
+  BEFORE UPDATE ON accounts
+  FOR EACH ROW
+  WHEN (OLD.balance IS DISTINCT FROM NEW.balance OR OLD.hold IS DISTINCT FROM NEW.hold)
+  EXECUTE FUNCTION accounts_version();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS accounts_version ON accounts;
+DROP FUNCTION IF EXISTS accounts_version();
+ALTER TABLE accounts DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
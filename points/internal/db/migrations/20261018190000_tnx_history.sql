@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- история транзакций счета с постраничным чтением по (commitdate, id)
+CREATE INDEX IF NOT EXISTS idx_tnx_account_history
+  ON tnx (pointaccount, commitdate DESC, id DESC) WHERE commit;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tnx_account_history;
+-- +goose StatementEnd
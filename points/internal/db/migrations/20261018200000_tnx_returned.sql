@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- начисления по возвращенным заказам не удаляются, а помечаются
+ALTER TABLE tnx
+  ADD COLUMN IF NOT EXISTS returned boolean NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tnx
+  DROP COLUMN IF EXISTS returned;
+-- +goose StatementEnd
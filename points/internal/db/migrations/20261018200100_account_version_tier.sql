@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- уровень кэшируется вместе с балансом: изменение уровня тоже увеличивает версию счета
+DROP TRIGGER IF EXISTS accounts_version ON accounts;
+CREATE TRIGGER accounts_version
+  BEFORE UPDATE ON accounts
+  FOR EACH ROW
+  WHEN (OLD.balance IS DISTINCT FROM NEW.balance OR OLD.hold IS DISTINCT FROM NEW.hold OR OLD.tier IS DISTINCT FROM NEW.tier)
+  EXECUTE FUNCTION accounts_version();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS accounts_version ON accounts;
+CREATE TRIGGER accounts_version
+  BEFORE UPDATE ON accounts
+  FOR EACH ROW
+  WHEN (OLD.balance IS DISTINCT FROM NEW.balance OR OLD.hold IS DISTINCT FROM NEW.hold)
+  EXECUTE FUNCTION accounts_version();
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- пачка захватывается relay до claimeduntil, сообщения, не отправленные за допустимое кол-во попыток, откладываются
+ALTER TABLE outbox
+  ADD COLUMN IF NOT EXISTS claimeduntil timestamptz,
+  ADD COLUMN IF NOT EXISTS parkedat     timestamptz;
+
+DROP INDEX IF EXISTS idx_outbox_unsent;
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent
+  ON outbox (id) WHERE sentat IS NULL AND parkedat IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_parked
+  ON outbox (parkedat) WHERE parkedat IS NOT NULL AND sentat IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_parked;
+DROP INDEX IF EXISTS idx_outbox_unsent;
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent
+  ON outbox (id) WHERE sentat IS NULL;
+ALTER TABLE outbox
+  DROP COLUMN IF EXISTS parkedat,
+  DROP COLUMN IF EXISTS claimeduntil;
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+package points
+
+import (
+	"context"
+	"encoding/json"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// канал LISTEN/NOTIFY событий движения баллов
+const NOTIFY_POINTS = "points_events"
+
+// уведомление о событиях движения баллов в рамках транзакции, доставляется слушателям после коммита
+func (p *PointsDB) notify(ctx context.Context, tx pgx.Tx, msgs ...model.OutboxMessage) error {
+	for _, msg := range msgs {
+		if msg.Topic != model.TOPIC_POINTS {
+			continue
+		}
+		_, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", NOTIFY_POINTS, string(msg.Payload))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Чтение событий движения баллов до отмены ctx или ошибки соединения
+// соединение занято слушателем до выхода, события, отправленные до LISTEN, не доставляются
+func (p *PointsDB) ListenEvents(ctx context.Context, handler func(event model.PointsEvent)) error {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "LISTEN "+NOTIFY_POINTS)
+	if err != nil {
+		return err
+	}
+	// соединение возвращается в пул без подписки
+	defer conn.Exec(context.Background(), "UNLISTEN "+NOTIFY_POINTS)
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var event model.PointsEvent
+		err = json.Unmarshal([]byte(n.Payload), &event)
+		if err != nil {
+			p.logger.Error("Invalid points event notification: " + err.Error())
+			continue
+		}
+		handler(event)
+	}
+}
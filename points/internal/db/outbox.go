@@ -0,0 +1,149 @@
+package points
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// Записать исходящие сообщения в рамках транзакции, события движения баллов также отправляются слушателям NOTIFY
+func (p *PointsDB) enqueue(ctx context.Context, tx pgx.Tx, msgs ...model.OutboxMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	insert := sq.Insert("outbox").
+		Columns("destination", "topic", "msgkey", "payload").
+		PlaceholderFormat(sq.Dollar)
+	for _, msg := range msgs {
+		insert = insert.Values(msg.Destination, msg.Topic, msg.Key, string(msg.Payload))
+	}
+	sql, args, err := insert.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	return p.notify(ctx, tx, msgs...)
+}
+
+// Записать исходящие сообщения без изменения данных
+func (p *PointsDB) OutboxAdd(ctx context.Context, msgs ...model.OutboxMessage) (err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	err = p.enqueue(ctx, tx, msgs...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// время, на которое relay захватывает пачку: другие relay ее не берут, после падения relay пачка снова доступна
+const outboxLease = time.Minute
+
+// Отправка пачки неотправленных сообщений по порядку: send вызывается для каждого сообщения,
+// успешно отправленные помечаются, на первой ошибке обработка пачки останавливается, чтобы сохранить порядок
+// пачка захватывается отдельной короткой транзакцией, блокировки строк во время отправки не удерживаются;
+// сообщение, не отправленное за maxAttempts попыток, откладывается (parkedat) и больше не блокирует очередь
+func (p *PointsDB) OutboxProcess(ctx context.Context, limit int, maxAttempts int, send func(msg model.OutboxMessage) error) (sent int, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	msgs, err := outboxClaim(ctx, conn, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, msg := range msgs {
+		senderr := send(msg)
+		if senderr != nil {
+			parked := msg.Attempts+1 >= maxAttempts
+			p.logger.Error("Outbox send error",
+				zap.Error(senderr),
+				zap.Int64("id", msg.ID),
+				zap.String("topic", msg.Topic),
+				zap.Int("attempts", msg.Attempts+1),
+				zap.Bool("parked", parked))
+			_, err = conn.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, lasterror = $1, claimeduntil = NULL,
+				parkedat = CASE WHEN $2::boolean THEN now() END WHERE id = $3`, senderr.Error(), parked, msg.ID)
+			if err != nil {
+				return sent, err
+			}
+			// остаток пачки освобождается для следующего опроса
+			var rest []int64
+			for _, m := range msgs[i+1:] {
+				rest = append(rest, m.ID)
+			}
+			if len(rest) > 0 {
+				_, err = conn.Exec(ctx, "UPDATE outbox SET claimeduntil = NULL WHERE id = ANY($1)", rest)
+				if err != nil {
+					return sent, err
+				}
+			}
+			break
+		}
+		_, err = conn.Exec(ctx, "UPDATE outbox SET sentat = $1, claimeduntil = NULL WHERE id = $2", time.Now(), msg.ID)
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// захват пачки неотправленных сообщений на outboxLease, параллельный relay возьмет следующие сообщения
+func outboxClaim(ctx context.Context, conn *pgxpool.Conn, limit int) (msgs []model.OutboxMessage, err error) {
+	rows, err := conn.Query(ctx, `UPDATE outbox SET claimeduntil = $1
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sentat IS NULL AND parkedat IS NULL AND (claimeduntil IS NULL OR claimeduntil < now())
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, destination, topic, msgkey, payload, attempts`, time.Now().Add(outboxLease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg model.OutboxMessage
+		var payload string
+		err = rows.Scan(&msg.ID, &msg.Destination, &msg.Topic, &msg.Key, &payload, &msg.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		msg.Payload = []byte(payload)
+		msgs = append(msgs, msg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING не сохраняет порядок подзапроса
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].ID < msgs[j].ID })
+	return msgs, nil
+}
@@ -47,60 +47,98 @@ func NewPointsDB(logger *zap.Logger) (db *PointsDB, err error) {
 	}
 	dsn := "postgres://" + user + ":" + password + "@" + purl + ":" + port + "/" + database
 
-	pool, err := pgxpool.New(context.Background(), dsn)
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	config.ConnConfig.Tracer = queryTracer{}
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
 	return &PointsDB{pool, logger}, err
 }
 
-// Создание транзакции начисления с датой в будущем
-func (p *PointsDB) TnxCreate(ctx context.Context, tnx model.PointTransaction) error {
+// проверка соединения с Postgres
+func (p *PointsDB) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+// Создание транзакций начисления по заказу с датой в будущем, по транзакции на кошелек
+func (p *PointsDB) TnxCreate(ctx context.Context, tnxs []model.PointTransaction) (err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	tnx.UUID = uuid.New()
-
-	sql, args, err := sq.Insert("tnx").
-		Columns("id", "pointaccount", "points", "commitdate", "typetnx", "orderid", "transferid", "redeemid").
-		Values(tnx.UUID, tnx.PointAccount, tnx.Points, tnx.CommitDate, model.ACCRUEL, tnx.OrderID, tnx.TransferID, tnx.RedeemID).
-		PlaceholderFormat(sq.Dollar).
-		ToSql()
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		p.logger.Error("SQL error",
-			zap.Error(err),
-			zap.String("query", sql),
-			zap.Any("args", args),
-		)
 		return err
 	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
 
-	_, err = conn.Exec(ctx, sql, args...)
-	if err != nil {
-		p.logger.Error("SQL error",
-			zap.Error(err),
-			zap.String("query", sql),
-			zap.Any("args", args),
-		)
-		return err
+	for _, tnx := range tnxs {
+		tnx.UUID = uuid.New()
+
+		sql, args, err := sq.Insert("tnx").
+			Columns("id", "pointaccount", "wallet", "points", "commitdate", "expiresat", "typetnx", "orderid", "transferid", "redeemid").
+			Values(tnx.UUID, tnx.PointAccount, tnx.Wallet, tnx.Points, tnx.CommitDate, nullTime(tnx.ExpiresAt), model.ACCRUEL, tnx.OrderID, tnx.TransferID, tnx.RedeemID).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			p.logger.Error("SQL error",
+				zap.Error(err),
+				zap.String("query", sql),
+				zap.Any("args", args),
+			)
+			return err
+		}
+
+		_, err = tx.Exec(ctx, sql, args...)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("order %s %w", tnx.OrderID, model.ErrAlreadyExists)
+			}
+			p.logger.Error("SQL error",
+				zap.Error(err),
+				zap.String("query", sql),
+				zap.Any("args", args),
+			)
+			return err
+		}
+
+		// событие: баллы ожидают зачисления, баланс не меняется
+		err = p.pendingEvent(ctx, tx, tnx.PointAccount, model.EVENT_ACCRUAL, tnx.Points, tnx.OrderID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// пустая дата - NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
 	}
-	return nil
+	return &t
 }
 
-// Создание пользователя
-func (p *PointsDB) UserCreate(ctx context.Context, userid string) (useruuid uuid.UUID, err error) {
+// Создание счета пользователя в кошельке, если счет уже создан параллельно - возвращается его UUID
+func (p *PointsDB) UserCreate(ctx context.Context, userid string, wallet string) (useruuid uuid.UUID, err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
 		return uuid.Nil, err
 	}
 	defer conn.Release()
 
-	useruuid = uuid.New()
-
 	sql, args, err := sq.Insert("accounts").
-		Columns("uuid", "userid", "balance").
+		Columns("uuid", "userid", "wallet", "balance").
 		PlaceholderFormat(sq.Dollar).
-		Values(useruuid, userid, 0).
+		Values(uuid.New(), userid, wallet, 0).
+		Suffix("ON CONFLICT (userid, wallet) DO UPDATE SET userid = EXCLUDED.userid RETURNING uuid").
 		ToSql()
 	if err != nil {
 		p.logger.Error("SQL error",
@@ -111,7 +149,7 @@ func (p *PointsDB) UserCreate(ctx context.Context, userid string) (useruuid uuid
 		return uuid.Nil, err
 	}
 
-	_, err = conn.Exec(ctx, sql, args...)
+	err = conn.QueryRow(ctx, sql, args...).Scan(&useruuid)
 	if err != nil {
 		p.logger.Error("SQL error",
 			zap.Error(err),
@@ -123,45 +161,130 @@ func (p *PointsDB) UserCreate(ctx context.Context, userid string) (useruuid uuid
 	return useruuid, nil
 }
 
-// Удаление транзакции (возвраты)
-func (p *PointsDB) TnxDelete(ctx context.Context, orderId string) error {
+// Возврат заказа: начисления по заказу помечаются возвращенными, строки транзакций не удаляются
+// зачисленные баллы списываются сторнирующей проводкой (баланс может стать отрицательным, если баллы уже потрачены),
+// не зачисленные - не будут зачислены заданием начисления; повторный возврат ничего не меняет
+func (p *PointsDB) TnxReturn(ctx context.Context, orderId string) (err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	sql, args, err := sq.Delete("tnx").
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	sql, args, err := sq.Update("tnx").
+		Set("returned", true).
 		Where(sq.Eq{"orderid": orderId}).
+		Where(sq.Eq{"typetnx": model.ACCRUEL}).
+		Where(sq.Eq{"returned": false}).
+		Suffix("RETURNING pointaccount, wallet, points, commit").
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
-		p.logger.Error("SQL error", zap.Error(err), zap.String("service", "TnxDelete"))
+		p.logger.Error("SQL error", zap.Error(err), zap.String("service", "TnxReturn"))
 		return err
 	}
 
-	_, err = conn.Exec(ctx, sql, args...)
+	rows, err := tx.Query(ctx, sql, args...)
 	if err != nil {
 		return err
 	}
-	return nil
+	type returned struct {
+		account uuid.UUID
+		wallet  string
+		points  float64
+		commit  bool
+	}
+	var tnxs []returned
+	for rows.Next() {
+		var r returned
+		err = rows.Scan(&r.account, &r.wallet, &r.points, &r.commit)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		tnxs = append(tnxs, r)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range tnxs {
+		// событие: начисление отменено, ожидающие зачисления баллы уменьшаются
+		if !r.commit {
+			err = p.pendingEvent(ctx, tx, r.account, model.EVENT_RETURN, -r.points, orderId)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// проводка: сторно начисления, событие return с отрицательным изменением баланса
+		err = p.postEntry(ctx, tx, model.NewJournalEntry(model.ENTRY_RETURN, orderId, r.account, model.LEDGER_ISSUANCE, r.points))
+		if err != nil {
+			return err
+		}
+		sql, args, err = sq.Insert("tnx").
+			Columns("id", "pointaccount", "wallet", "points", "commitdate", "typetnx", "orderid", "commit").
+			Values(uuid.New(), r.account, r.wallet, r.points, time.Now(), model.RETURN, orderId, true).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// событие изменения баллов, ожидающих зачисления, баланс счета не меняется
+func (p *PointsDB) pendingEvent(ctx context.Context, tx pgx.Tx, account uuid.UUID, eventType string, pending float64, correlationId string) error {
+	var user, wallet string
+	var balance float64
+	err := tx.QueryRow(ctx, "SELECT userid, wallet, balance FROM accounts WHERE uuid = $1", account).Scan(&user, &wallet, &balance)
+	if err != nil {
+		return err
+	}
+	event := model.NewPointsEvent(eventType, user, wallet, 0, balance, correlationId)
+	event.Pending = pending
+	msg, err := event.Message()
+	if err != nil {
+		return err
+	}
+	return p.enqueue(ctx, tx, msg)
 }
 
 // Зачисление баллов - обработка транзакции с наступившей датой
-func (p *PointsDB) TnxCommitOnDate(ctx context.Context, date time.Time) error {
+// Возвращает счета, на которые зачислены баллы, с новыми балансами и кол-во счетов с ошибкой
+func (p *PointsDB) TnxCommitOnDate(ctx context.Context, date time.Time) (result model.CommitResult, err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
 		p.logger.Error("Get connection error", zap.Error(err), zap.String("service", "TnxCommitOnDate"))
-		return err
+		return result, err
 	}
 	defer conn.Release()
 
-	// получить все транзакции для коммита - сгруппировать по счетам
-	sql, args, err := sq.Select("pointaccount", "SUM(points) as points").
+	// получить счета, по которым есть транзакции для коммита
+	sql, args, err := sq.Select("pointaccount").
+		Distinct().
 		From("tnx").
 		Where(sq.Eq{"commit": false}).
+		Where(sq.Eq{"typetnx": model.ACCRUEL}).
+		Where(sq.Eq{"returned": false}).
 		Where(sq.LtOrEq{"commitdate": date}).
-		GroupBy("pointaccount").
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
@@ -170,13 +293,13 @@ func (p *PointsDB) TnxCommitOnDate(ctx context.Context, date time.Time) error {
 			zap.String("query", sql),
 			zap.Any("args", args),
 		)
-		return err
+		return result, err
 	}
 
 	rows, err := conn.Query(ctx, sql, args...)
 	if err != nil {
 		p.logger.Error("Query get tnx error", zap.Error(err), zap.String("service", "TnxCommitOnDate"))
-		return err
+		return result, err
 	}
 	defer rows.Close()
 
@@ -198,113 +321,122 @@ func (p *PointsDB) TnxCommitOnDate(ctx context.Context, date time.Time) error {
 	// семафор
 	semch := make(chan struct{}, semcount)
 	wg := &sync.WaitGroup{}
+	mu := &sync.Mutex{}
 
 	// обработка счетов
 	for rows.Next() {
 		var balance uuid.UUID
-		var points float64
-		rows.Scan(&balance, &points)
+		err = rows.Scan(&balance)
+		if err != nil {
+			p.logger.Error("Scan account error", zap.Error(err), zap.String("service", "TnxCommitOnDate"))
+			mu.Lock()
+			result.Failed++
+			mu.Unlock()
+			continue
+		}
 
 		semch <- struct{}{}
 		wg.Add(1)
-		go func(balance uuid.UUID, points float64) {
+		go func(balance uuid.UUID) {
 			defer func() {
 				wg.Done()
 				<-semch
 			}()
 
-			conn, err := p.pool.Acquire(ctx)
+			committed, err := p.commitAccount(ctx, balance, date)
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				p.logger.Error("Get connection error",
+				p.logger.Error("Commit account error",
 					zap.Error(err),
 					zap.String("service", "TnxCommitOnDate"),
 					zap.String("balance", balance.String()))
+				result.Failed++
 				return
 			}
-			defer conn.Release()
-
-			tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
-			if err != nil {
-				p.logger.Error("Begin tx error",
-					zap.Error(err),
-					zap.String("service", "TnxCommitOnDate"),
-					zap.String("balance", balance.String()))
-				return
+			if committed.Points != 0 {
+				result.Accounts = append(result.Accounts, committed)
 			}
-			var erroroccured bool
-			defer func() {
-				if erroroccured == true {
-					tx.Rollback(ctx)
-				}
-			}()
+		}(balance)
 
-			// блокируем строку с балансом
-			var currentb float64
-			row := tx.QueryRow(ctx, "SELECT balance from ACCOUNTS where uuid = $1 FOR UPDATE", balance)
-			err = row.Scan(&currentb)
-			if err != nil {
-				p.logger.Error("Block balance error",
-					zap.Error(err),
-					zap.String("service", "TnxCommitOnDate"),
-					zap.String("balance", balance.String()))
-				erroroccured = true
-				return
-			}
+	}
+	wg.Wait()
+	return result, rows.Err()
+}
 
-			currentb += points
+// Зачисление баллов на один счет: разметка транзакций и проводка на сумму размеченных транзакций
+func (p *PointsDB) commitAccount(ctx context.Context, balance uuid.UUID, date time.Time) (committed model.CommittedAccount, err error) {
+	committed.Account = balance
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return committed, err
+	}
+	defer conn.Release()
 
-			// обновляем баланс
-			sql, args, err := sq.Update("accounts").
-				Set("balance", currentb).
-				Where(sq.Eq{"uuid": balance}).
-				PlaceholderFormat(sq.Dollar).
-				ToSql()
-			_, err = tx.Exec(ctx, sql, args...)
-			if err != nil {
-				p.logger.Error("Update balance error",
-					zap.Error(err),
-					zap.String("service", "TnxCommitOnDate"),
-					zap.String("balance", balance.String()))
-				erroroccured = true
-				return
-			}
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return committed, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
 
-			// ставим флаг на транзакции
-			sql, args, err = sq.Update("tnx").
-				Set("commit", true).
-				Where(sq.Eq{"pointaccount": balance}).
-				Where(sq.Eq{"commit": false}).
-				Where(sq.LtOrEq{"commitdate": date}).
-				PlaceholderFormat(sq.Dollar).
-				ToSql()
-			_, err = tx.Exec(ctx, sql, args...)
-			if err != nil {
-				p.logger.Error("Commit tnx error",
-					zap.Error(err),
-					zap.String("service", "TnxCommitOnDate"),
-					zap.String("balance", balance.String()))
-				erroroccured = true
-				return
-			}
-			err = tx.Commit(ctx)
-			if err != nil {
-				p.logger.Error("Commit error",
-					zap.Error(err),
-					zap.String("service", "TnxCommitOnDate"),
-					zap.String("balance", balance.String()))
-				erroroccured = true
-				return
-			}
+	// ставим флаг на транзакции, сумма берется только по реально размеченным строкам
+	sql, args, err := sq.Update("tnx").
+		Set("commit", true).
+		Where(sq.Eq{"pointaccount": balance}).
+		Where(sq.Eq{"commit": false}).
+		Where(sq.Eq{"typetnx": model.ACCRUEL}).
+		Where(sq.Eq{"returned": false}).
+		Where(sq.LtOrEq{"commitdate": date}).
+		Suffix("RETURNING points").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return committed, err
+	}
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return committed, err
+	}
+	var points float64
+	for rows.Next() {
+		var tnxpoints float64
+		err = rows.Scan(&tnxpoints)
+		if err != nil {
+			rows.Close()
+			return committed, err
+		}
+		points += tnxpoints
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return committed, err
+	}
 
-		}(balance, points)
+	// проводка: выпуск баллов на счет пользователя
+	if points != 0 {
+		err = p.postEntry(ctx, tx, model.NewJournalEntry(model.ENTRY_ACCRUAL, date.Format(time.RFC3339), model.LEDGER_ISSUANCE, balance, points))
+		if err != nil {
+			return committed, err
+		}
+	}
 
+	// баланс после зачисления
+	row := tx.QueryRow(ctx, "SELECT userid, wallet, balance FROM accounts WHERE uuid = $1", balance)
+	err = row.Scan(&committed.UserId, &committed.Wallet, &committed.Balance)
+	if err != nil {
+		return committed, err
 	}
-	wg.Wait()
-	return nil
+	committed.Points = points
+
+	return committed, tx.Commit(ctx)
 }
 
-// Списание
-func (p *PointsDB) Redeem(ctx context.Context, user string, points float64, redeemId string) (err error) {
+// Списание из кошелька
+func (p *PointsDB) Redeem(ctx context.Context, user string, wallet string, points float64, redeemId string, events ...model.OutboxMessage) (err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
 		return err
@@ -322,37 +454,33 @@ func (p *PointsDB) Redeem(ctx context.Context, user string, points float64, rede
 	}()
 
 	// проверить и заблокировать баланс
-	var currentb float64
+	var currentb, hold float64
 	var account uuid.UUID
 	var pguuid pgtype.UUID
-	row := tx.QueryRow(ctx, "SELECT uuid, balance from ACCOUNTS where userid = $1 FOR UPDATE", user)
-	if err != nil {
-		return err
-	}
-	err = row.Scan(&pguuid, &currentb)
+	row := tx.QueryRow(ctx, "SELECT uuid, balance, hold from ACCOUNTS where userid = $1 AND wallet = $2 FOR UPDATE", user, wallet)
+	err = row.Scan(&pguuid, &currentb, &hold)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user wallet %s %w", wallet, model.ErrNotFound)
+		}
 		return err
 	}
 	account, _ = uuid.FromBytes(pguuid.Bytes[:])
-	if currentb < points {
-		return fmt.Errorf("Not enough points")
-	}
-	currentb -= points
-	// обновляем баланс
-	sql, args, err := sq.Update("accounts").
-		Set("balance", currentb).
-		Where(sq.Eq{"userid": user}).
-		PlaceholderFormat(sq.Dollar).
-		ToSql()
-	_, err = tx.Exec(ctx, sql, args...)
+	// зарезервированные баллы недоступны для списания
+	if currentb-hold < points {
+		return model.ErrNotEnoughPoints
+	}
+
+	// проводка: погашение баллов
+	err = p.postEntry(ctx, tx, model.NewJournalEntry(model.ENTRY_REDEEM, redeemId, account, model.LEDGER_REDEMPTION, points))
 	if err != nil {
 		return err
 	}
 
 	// добавить транзакцию списания
-	sql, args, err = sq.Insert("tnx").
-		Columns("id", "pointaccount", "points", "commitdate", "typetnx", "redeemid", "commit").
-		Values(uuid.New(), account, points, time.Now(), model.REDEEM, redeemId, true).
+	sql, args, err := sq.Insert("tnx").
+		Columns("id", "pointaccount", "wallet", "points", "commitdate", "typetnx", "redeemid", "commit").
+		Values(uuid.New(), account, wallet, points, time.Now(), model.REDEEM, redeemId, true).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
@@ -361,16 +489,21 @@ func (p *PointsDB) Redeem(ctx context.Context, user string, points float64, rede
 
 	_, err = tx.Exec(ctx, sql, args...)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("redeem %s %w", redeemId, model.ErrAlreadyExists)
+		}
 		return err
 	}
-	tx.Commit(ctx)
-	return nil
-	// обновить баланс
 
+	err = p.enqueue(ctx, tx, events...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-// Перевод баллов
-func (p *PointsDB) Transfer(ctx context.Context, userfrom string, userto string, points float64, transferId string) (err error) {
+// Перевод баллов между кошельками пользователей одного типа, счет получателя в кошельке создается при первом переводе
+func (p *PointsDB) Transfer(ctx context.Context, userfrom string, userto string, wallet string, points float64, transferId string) (err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
 		return err
@@ -387,37 +520,65 @@ func (p *PointsDB) Transfer(ctx context.Context, userfrom string, userto string,
 		}
 	}()
 
-	// проверить и заблокировать баланс
-	var currentb float64
-	var account uuid.UUID
-	var pguuid pgtype.UUID
-	row := tx.QueryRow(ctx, "SELECT uuid, balance from ACCOUNTS where userid = $1 FOR UPDATE", userfrom)
+	// счет получателя в кошельке создается, только если получатель известен - у него есть счет в любом кошельке;
+	// счета не удаляются, поэтому проверки без блокировки достаточно, счет в кошельке блокируется ниже вместе со счетом отправителя
+	_, err = tx.Exec(ctx, `INSERT INTO accounts (uuid, userid, wallet, balance)
+		SELECT $1, $2, $3, 0 WHERE EXISTS (SELECT 1 FROM accounts WHERE userid = $2)
+		ON CONFLICT (userid, wallet) DO NOTHING`, uuid.New(), userto, wallet)
 	if err != nil {
 		return err
 	}
-	err = row.Scan(&pguuid, &currentb)
+
+	// заблокировать оба счета в одном порядке, чтобы встречные переводы не вызывали deadlock
+	rows, err := tx.Query(ctx, "SELECT uuid, userid, balance, hold from ACCOUNTS where userid = ANY($1) AND wallet = $2 ORDER BY uuid FOR UPDATE", []string{userfrom, userto}, wallet)
 	if err != nil {
 		return err
 	}
-	account, _ = uuid.FromBytes(pguuid.Bytes[:])
-	if currentb < points {
-		return fmt.Errorf("Not enough points")
-	}
-	currentb -= points
-	// обновляем баланс
-	sql, args, err := sq.Update("accounts").
-		Set("balance", currentb).
-		Where(sq.Eq{"userid": userfrom}).
-		PlaceholderFormat(sq.Dollar).
-		ToSql()
-	_, err = tx.Exec(ctx, sql, args...)
+	type lockedAccount struct {
+		uuid    uuid.UUID
+		balance float64
+		hold    float64
+	}
+	locked := make(map[string]lockedAccount, 2)
+	for rows.Next() {
+		var acc lockedAccount
+		var userid string
+		err = rows.Scan(&acc.uuid, &userid, &acc.balance, &acc.hold)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		locked[userid] = acc
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	from, ok := locked[userfrom]
+	if !ok {
+		return fmt.Errorf("user %s %w", userfrom, model.ErrNotFound)
+	}
+	to, ok := locked[userto]
+	if !ok {
+		return fmt.Errorf("user %s %w", userto, model.ErrNotFound)
+	}
+	// зарезервированные баллы недоступны для перевода
+	if from.balance-from.hold < points {
+		return model.ErrNotEnoughPoints
+	}
+
+	// проводка: перевод между счетами пользователей
+	err = p.postEntry(ctx, tx, model.NewJournalEntry(model.ENTRY_TRANSFER, transferId, from.uuid, to.uuid, points))
 	if err != nil {
 		return err
 	}
-	// добавить транзакцию списания
-	sql, args, err = sq.Insert("tnx").
-		Columns("id", "pointaccount", "points", "commitdate", "typetnx", "transferid").
-		Values(uuid.New(), account, points, time.Now(), model.REDEEM, transferId).
+
+	// добавить транзакции списания и начисления, обе сразу обработаны
+	now := time.Now()
+	sql, args, err := sq.Insert("tnx").
+		Columns("id", "pointaccount", "wallet", "points", "commitdate", "typetnx", "transferid", "commit").
+		Values(uuid.New(), from.uuid, wallet, points, now, model.REDEEM, transferId, true).
+		Values(uuid.New(), to.uuid, wallet, points, now, model.ACCRUEL, transferId, true).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
@@ -425,35 +586,59 @@ func (p *PointsDB) Transfer(ctx context.Context, userfrom string, userto string,
 	}
 	_, err = tx.Exec(ctx, sql, args...)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("transfer %s %w", transferId, model.ErrAlreadyExists)
+		}
 		return err
 	}
 
-	// user 2
-	row = tx.QueryRow(ctx, "SELECT uuid, balance from ACCOUNTS where userid = $1 FOR UPDATE", userto)
+	return tx.Commit(ctx)
+}
+
+// Ручная корректировка баланса кошелька, points со знаком
+func (p *PointsDB) Adjust(ctx context.Context, user string, wallet string, points float64, adjustId string, reason string) (err error) {
+	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
 		return err
 	}
+	defer conn.Release()
 
-	err = row.Scan(&pguuid, &currentb)
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
-	account, _ = uuid.FromBytes(pguuid.Bytes[:])
-	currentb += points
-	// обновляем баланс
-	sql, args, err = sq.Update("accounts").
-		Set("balance", currentb).
-		Where(sq.Eq{"userid": userto}).
-		PlaceholderFormat(sq.Dollar).
-		ToSql()
-	_, err = tx.Exec(ctx, sql, args...)
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// проверить и заблокировать баланс
+	var currentb, hold float64
+	var account uuid.UUID
+	row := tx.QueryRow(ctx, "SELECT uuid, balance, hold from ACCOUNTS where userid = $1 AND wallet = $2 FOR UPDATE", user, wallet)
+	err = row.Scan(&account, &currentb, &hold)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user wallet %s %w", wallet, model.ErrNotFound)
+		}
 		return err
 	}
-	// добавить транзакцию начисленияs
-	sql, args, err = sq.Insert("tnx").
-		Columns("id", "pointaccount", "points", "commitdate", "typetnx", "transferid").
-		Values(uuid.New(), account, points, time.Now(), model.ACCRUEL, transferId).
+	// списание не может затронуть зарезервированные баллы
+	if currentb-hold+points < 0 {
+		return model.ErrNotEnoughPoints
+	}
+
+	// проводка: корректировка за счет системного счета корректировок
+	err = p.postEntry(ctx, tx, model.NewJournalEntry(model.ENTRY_ADJUST, adjustId, model.LEDGER_ADJUSTMENT, account, points))
+	if err != nil {
+		return err
+	}
+
+	// добавить транзакцию корректировки
+	sql, args, err := sq.Insert("tnx").
+		Columns("id", "pointaccount", "wallet", "points", "commitdate", "typetnx", "adjustid", "reason", "commit").
+		Values(uuid.New(), account, wallet, points, time.Now(), model.ADJUST, adjustId, reason, true).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
@@ -461,37 +646,55 @@ func (p *PointsDB) Transfer(ctx context.Context, userfrom string, userto string,
 	}
 	_, err = tx.Exec(ctx, sql, args...)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("adjust %s %w", adjustId, model.ErrAlreadyExists)
+		}
 		return err
 	}
 
-	tx.Commit(ctx)
-	return nil
-
+	return tx.Commit(ctx)
 }
 
-// Получить баланс
-func (p *PointsDB) GetBalance(ctx context.Context, user string) (points float64, err error) {
+// Получить доступные балансы кошельков пользователя, уровень статуса и версию - сумму версий счетов
+func (p *PointsDB) GetBalance(ctx context.Context, user string) (balance model.BalanceVersion, err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
-		return 0, err
+		return balance, err
 	}
 	defer conn.Release()
 
-	row := conn.QueryRow(ctx, "SELECT balance FROM accounts WHERE userid = $1", user)
-	err = row.Scan(&points)
-	p.logger.Info("points",
-		zap.Any("points", points),
-	)
+	// доступный баланс - без учета зарезервированных баллов
+	rows, err := conn.Query(ctx, "SELECT wallet, balance - hold, version, tier FROM accounts WHERE userid = $1", user)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, fmt.Errorf("user %w", model.ErrNotFound)
+		return balance, err
+	}
+	defer rows.Close()
+
+	balance.Balances = make(map[string]float64)
+	for rows.Next() {
+		var wallet, tier string
+		var points float64
+		var version int64
+		err = rows.Scan(&wallet, &points, &version, &tier)
+		if err != nil {
+			return model.BalanceVersion{}, err
+		}
+		balance.Balances[wallet] = points
+		balance.Version += version
+		if wallet == model.WALLET_BASE {
+			balance.Tier = tier
 		}
-		return 0, err
 	}
-	return points, nil
+	if err = rows.Err(); err != nil {
+		return model.BalanceVersion{}, err
+	}
+	if len(balance.Balances) == 0 {
+		return model.BalanceVersion{}, fmt.Errorf("user %w", model.ErrNotFound)
+	}
+	return balance, nil
 }
 
-// Получить транзакции
+// Получить транзакции по всем кошелькам пользователя
 func (p *PointsDB) GetTnx(ctx context.Context, user string, from time.Time, to time.Time) (tnxs []model.PointTransaction, err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
@@ -499,22 +702,27 @@ func (p *PointsDB) GetTnx(ctx context.Context, user string, from time.Time, to t
 	}
 	defer conn.Release()
 
-	var account uuid.UUID
-	var pguuid pgtype.UUID
-	row := conn.QueryRow(ctx, "SELECT uuid from ACCOUNTS where userid = $1", user)
-	err = row.Scan(&pguuid)
+	var exists bool
+	row := conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM accounts WHERE userid = $1)", user)
+	err = row.Scan(&exists)
 	if err != nil {
 		return nil, err
 	}
-	account, _ = uuid.FromBytes(pguuid.Bytes[:])
-	sql, args, err := sq.Select("id", "pointaccount", "points", "commitdate", "typetnx", "orderid", "transferid", "redeemid").
-		From("tnx").
-		Where(sq.Eq{"pointaccount": account}).
-		Where(sq.Eq{"commit": true}).
-		Where(sq.GtOrEq{"commitdate": from}).
-		Where(sq.LtOrEq{"commitdate": to}).
+	if !exists {
+		return nil, fmt.Errorf("user %w", model.ErrNotFound)
+	}
+	sql, args, err := sq.Select(tnxColumns...).
+		From("tnx t").
+		Join("accounts a ON a.uuid = t.pointaccount").
+		Where(sq.Eq{"a.userid": user}).
+		Where(sq.Eq{"t.commit": true}).
+		Where(sq.GtOrEq{"t.commitdate": from}).
+		Where(sq.LtOrEq{"t.commitdate": to}).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
+	if err != nil {
+		return nil, err
+	}
 	rows, err := conn.Query(ctx, sql, args...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -522,37 +730,120 @@ func (p *PointsDB) GetTnx(ctx context.Context, user string, from time.Time, to t
 		}
 		return nil, err
 	}
+	defer rows.Close()
+	return scanTnxs(rows)
+}
+
+// поля транзакции для scanTnxs
+var tnxColumns = []string{"t.id", "t.pointaccount", "t.wallet", "t.points", "t.commitdate", "t.expiresat", "t.typetnx", "t.orderid", "t.transferid", "t.redeemid", "t.adjustid", "t.reason"}
+
+// Получить страницу истории обработанных транзакций пользователя по фильтру
+// сортировка по дате транзакции и UUID в обратном порядке, страница начинается после filter.After
+func (p *PointsDB) ListTnx(ctx context.Context, filter model.TnxFilter) (tnxs []model.PointTransaction, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var exists bool
+	row := conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM accounts WHERE userid = $1)", filter.User)
+	err = row.Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("user %w", model.ErrNotFound)
+	}
+
+	query := sq.Select(tnxColumns...).
+		From("tnx t").
+		Join("accounts a ON a.uuid = t.pointaccount").
+		Where(sq.Eq{"a.userid": filter.User}).
+		Where(sq.Eq{"t.commit": true})
+	if !filter.From.IsZero() {
+		query = query.Where(sq.GtOrEq{"t.commitdate": filter.From})
+	}
+	if !filter.To.IsZero() {
+		query = query.Where(sq.LtOrEq{"t.commitdate": filter.To})
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where(sq.Eq{"t.typetnx": filter.Types})
+	}
+	if filter.Wallet != "" {
+		query = query.Where(sq.Eq{"t.wallet": filter.Wallet})
+	}
+	if filter.OrderID != "" {
+		query = query.Where(sq.Eq{"t.orderid": filter.OrderID})
+	}
+	if filter.RedeemID != "" {
+		query = query.Where(sq.Eq{"t.redeemid": filter.RedeemID})
+	}
+	if filter.After != nil {
+		query = query.Where("(t.commitdate, t.id) < (?, ?)", filter.After.CommitDate, filter.After.UUID)
+	}
+	sql, args, err := query.
+		OrderBy("t.commitdate DESC", "t.id DESC").
+		Limit(uint64(filter.Limit)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTnxs(rows)
+}
+
+// чтение транзакций, выбранных по tnxColumns
+func scanTnxs(rows pgx.Rows) (tnxs []model.PointTransaction, err error) {
 	var tnx model.PointTransaction
+	var ExpiresAt *time.Time
 	var OrderID pgtype.Text
 	var TransferID pgtype.Text
 	var RedeemID pgtype.Text
+	var AdjustID pgtype.Text
+	var Reason pgtype.Text
 	for rows.Next() {
-		err = rows.Scan(&tnx.UUID, &tnx.PointAccount, &tnx.Points, &tnx.CommitDate, &tnx.TypeTnx, &OrderID, &TransferID, &RedeemID)
+		err = rows.Scan(&tnx.UUID, &tnx.PointAccount, &tnx.Wallet, &tnx.Points, &tnx.CommitDate, &ExpiresAt, &tnx.TypeTnx, &OrderID, &TransferID, &RedeemID, &AdjustID, &Reason)
 		if err != nil {
 			return nil, err
 		}
+		tnx.ExpiresAt = time.Time{}
+		if ExpiresAt != nil {
+			tnx.ExpiresAt = *ExpiresAt
+		}
 		tnx.OrderID = OrderID.String
 		tnx.TransferID = TransferID.String
 		tnx.RedeemID = RedeemID.String
+		tnx.AdjustID = AdjustID.String
+		tnx.Reason = Reason.String
 		tnxs = append(tnxs, tnx)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 	return tnxs, nil
 }
 
-// Получить UUID аккаунта
-func (p *PointsDB) GetUserUUID(ctx context.Context, user string) (account uuid.UUID, err error) {
+// Получить UUID счета пользователя в кошельке, счет создается при первом обращении
+func (p *PointsDB) GetUserUUID(ctx context.Context, user string, wallet string) (account uuid.UUID, err error) {
 	conn, err := p.pool.Acquire(ctx)
 	if err != nil {
 		return uuid.Nil, err
 	}
+	defer conn.Release()
 
-	row := conn.QueryRow(ctx, "SELECT uuid FROM accounts WHERE userid = $1", user)
+	row := conn.QueryRow(ctx, "SELECT uuid FROM accounts WHERE userid = $1 AND wallet = $2", user, wallet)
 	var pguuid pgtype.UUID
 
 	err = row.Scan(&pguuid)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			account, err = p.UserCreate(ctx, user)
+			account, err = p.UserCreate(ctx, user, wallet)
 			return account, err
 		}
 		return uuid.Nil, err
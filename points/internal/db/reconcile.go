@@ -0,0 +1,98 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// баланс по обработанным транзакциям: начисления и корректировки со своим знаком, списания ($1), сгорания ($2) и сторно ($3) с минусом;
+// обработанные транзакции - источник истины, баланс счета мог разойтись с ними
+const expectedBalanceSQL = `COALESCE(SUM(CASE WHEN t.typetnx IN ($1, $2, $3) THEN -t.points ELSE t.points END) FILTER (WHERE t.commit), 0)`
+
+// типы транзакций, уменьшающих баланс, - аргументы expectedBalanceSQL
+var expectedBalanceArgs = []any{model.REDEEM, model.EXPIRY, model.RETURN}
+
+// Сверка балансов счетов с обработанными транзакциями, в отчете и остаток по журналу проводок
+func (p *PointsDB) ReconcileBalances(ctx context.Context) (drifts []model.BalanceDrift, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, `SELECT a.uuid, a.userid, a.wallet, a.balance, e.expected, l.ledger
+		FROM accounts a
+		CROSS JOIN LATERAL (SELECT `+expectedBalanceSQL+` AS expected FROM tnx t WHERE t.pointaccount = a.uuid) e
+		CROSS JOIN LATERAL (SELECT COALESCE(SUM(j.amount), 0) AS ledger FROM journal_lines j WHERE j.account = a.uuid) l
+		WHERE a.balance <> e.expected OR a.balance <> l.ledger
+		ORDER BY a.userid`, expectedBalanceArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var drift model.BalanceDrift
+		err = rows.Scan(&drift.Account, &drift.UserId, &drift.Wallet, &drift.Balance, &drift.Expected, &drift.Ledger)
+		if err != nil {
+			return nil, err
+		}
+		drift.Difference = math.Round((drift.Balance-drift.Expected)*100) / 100
+		drifts = append(drifts, drift)
+	}
+	return drifts, rows.Err()
+}
+
+// Исправление баланса счета по обработанным транзакциям: проводка корректировки на сумму расхождения,
+// баланс и журнал изменяются вместе, история транзакций не изменяется. Расхождение пересчитывается под блокировкой счета
+func (p *PointsDB) ReconcileBalance(ctx context.Context, account uuid.UUID, date time.Time) (drift model.BalanceDrift, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return drift, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return drift, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// заблокировать счет, чтобы баланс и транзакции не изменились во время корректировки
+	row := tx.QueryRow(ctx, "SELECT uuid, userid, wallet, balance from ACCOUNTS where uuid = $1 FOR UPDATE", account)
+	err = row.Scan(&drift.Account, &drift.UserId, &drift.Wallet, &drift.Balance)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return drift, fmt.Errorf("account %w", model.ErrNotFound)
+		}
+		return drift, err
+	}
+	row = tx.QueryRow(ctx, "SELECT "+expectedBalanceSQL+" FROM tnx t WHERE t.pointaccount = $4", append(expectedBalanceArgs, account)...)
+	err = row.Scan(&drift.Expected)
+	if err != nil {
+		return drift, err
+	}
+	drift.Difference = math.Round((drift.Balance-drift.Expected)*100) / 100
+	if drift.Difference == 0 {
+		return drift, tx.Commit(ctx)
+	}
+
+	// проводка: баланс приводится к транзакциям за счет системного счета корректировок
+	reference := "reconcile:" + account.String() + ":" + date.Format(time.RFC3339)
+	err = p.postEntry(ctx, tx, model.NewJournalEntry(model.ENTRY_ADJUST, reference, model.LEDGER_ADJUSTMENT, account, -drift.Difference))
+	if err != nil {
+		return drift, err
+	}
+	return drift, tx.Commit(ctx)
+}
@@ -0,0 +1,29 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Получить правила списания кошелька
+func (p *PointsDB) GetRedeemRule(ctx context.Context, wallet string) (rule model.RedeemRule, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return rule, err
+	}
+	defer conn.Release()
+
+	row := conn.QueryRow(ctx, "SELECT wallet, rate, maxshare, minpoints, categories FROM redeem_rules WHERE wallet = $1", wallet)
+	err = row.Scan(&rule.Wallet, &rule.Rate, &rule.MaxShare, &rule.MinPoints, &rule.Categories)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rule, fmt.Errorf("redeem rule for wallet %s %w", wallet, model.ErrNotFound)
+		}
+		return rule, err
+	}
+	return rule, nil
+}
@@ -0,0 +1,142 @@
+package points
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Получить уровни статуса по возрастанию
+func (p *PointsDB) GetTiers(ctx context.Context) (tiers []model.Tier, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, "SELECT code, name, threshold, rank FROM tiers ORDER BY rank")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tier model.Tier
+		err = rows.Scan(&tier.Code, &tier.Name, &tier.Threshold, &tier.Rank)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, tier)
+	}
+	return tiers, rows.Err()
+}
+
+// Активность всех счетов с даты from: начисленные или списанные баллы основного кошелька без учета переводов
+// Уровень хранится на счете основного кошелька
+func (p *PointsDB) TierActivity(ctx context.Context, from time.Time, basis string) (activity []model.TierActivity, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	sql, args, err := tierActivityQuery(from, basis).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a model.TierActivity
+		err = rows.Scan(&a.Account, &a.UserId, &a.Tier, &a.TierChangedAt, &a.Activity)
+		if err != nil {
+			return nil, err
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// запрос активности: начисления по возвращенным заказам не учитываются
+func tierActivityQuery(from time.Time, basis string) sq.SelectBuilder {
+	typetnx := model.ACCRUEL
+	if basis == model.TIER_BASIS_SPENT {
+		typetnx = model.REDEEM
+	}
+	return sq.Select("a.uuid", "a.userid", "a.tier", "a.tierchangedat", "COALESCE(SUM(t.points), 0)").
+		From("accounts a").
+		LeftJoin(`tnx t ON t.pointaccount = a.uuid
+			AND t.commit AND NOT t.returned AND t.typetnx = ? AND COALESCE(t.transferid, '') = '' AND t.commitdate >= ?`, typetnx, from).
+		Where(sq.Eq{"a.wallet": model.WALLET_BASE}).
+		GroupBy("a.uuid", "a.userid", "a.tier", "a.tierchangedat").
+		PlaceholderFormat(sq.Dollar)
+}
+
+// Изменить уровень счета с записью в историю, changed = false - уровень уже изменен параллельно
+func (p *PointsDB) SetTier(ctx context.Context, account uuid.UUID, oldTier string, newTier string, activity float64, date time.Time, events ...model.OutboxMessage) (changed bool, err error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	sql, args, err := sq.Update("accounts").
+		Set("tier", newTier).
+		Set("tierchangedat", date).
+		Where(sq.Eq{"uuid": account}).
+		Where(sq.Eq{"tier": oldTier}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, tx.Rollback(ctx)
+	}
+
+	sql, args, err = sq.Insert("tier_history").
+		Columns("id", "pointaccount", "oldtier", "newtier", "activity", "changedat").
+		Values(uuid.New(), account, oldTier, newTier, activity, date).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	err = p.enqueue(ctx, tx, events...)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
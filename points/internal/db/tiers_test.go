@@ -0,0 +1,35 @@
+package points
+
+import (
+	"testing"
+	"time"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTierActivityQuery(t *testing.T) {
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		basis   string
+		typetnx int
+	}{
+		{"начисленные баллы", model.TIER_BASIS_EARNED, model.ACCRUEL},
+		{"списанные баллы", model.TIER_BASIS_SPENT, model.REDEEM},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			sql, args, err := tierActivityQuery(from, ts.basis).ToSql()
+			require.NoError(t, err)
+			// начисление по возвращенному заказу не увеличивает активность
+			require.Contains(t, sql, "NOT t.returned")
+			require.Contains(t, sql, "t.typetnx = $1")
+			require.Contains(t, sql, "t.commitdate >= $2")
+			require.Contains(t, sql, "a.wallet = $3")
+			require.Equal(t, []any{ts.typetnx, from, model.WALLET_BASE}, args)
+		})
+	}
+}
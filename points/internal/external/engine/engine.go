@@ -8,52 +8,94 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sync"
 	"time"
+
+	"github.com/glkeru/loyalty/auth"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type CalculateResponse struct {
-	Points int32 `json:"points"`
+	Points  int32            `json:"points"`  // баллы по всем кошелькам
+	Wallets map[string]int32 `json:"wallets"` // баллы по кошелькам начисления
 }
 
-func CalculateOrder(ctx context.Context, orderJson string) (points int32, err error) {
+// HTTP клиент Engine: при заданных POINTS_ENGINE_TLS_* - TLS с клиентским сертификатом (mTLS), ENGINE_HOST с https://
+// запросы в трассировке обработки заказа
+var engineClient = sync.OnceValues(func() (*http.Client, error) {
+	config, err := auth.ClientTLS("POINTS_ENGINE")
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	// контекст трассировки передается в Engine заголовком traceparent
+	return &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: otelhttp.NewTransport(transport),
+	}, nil
+})
+
+// Расчет баллов по заказу, возвращает баллы по кошелькам начисления
+func CalculateOrder(ctx context.Context, orderJson string) (wallets map[string]int32, err error) {
 
 	// config
 	host := os.Getenv("ENGINE_HOST")
 	if host == "" {
-		return 0, fmt.Errorf("env ENGINE_HOST is not set")
+		return nil, fmt.Errorf("env ENGINE_HOST is not set")
 	}
 	port := os.Getenv("ENGINE_PORT")
 	if port == "" {
-		return 0, fmt.Errorf("env ENGINE_PORT is not set")
+		return nil, fmt.Errorf("env ENGINE_PORT is not set")
 	}
 
 	// вызов расчета баллов
-	client := &http.Client{Timeout: 5 * time.Second}
+	client, err := engineClient()
+	if err != nil {
+		return nil, err
+	}
 	orderData := []byte(orderJson)
-	req, err := http.NewRequest("POST", host+":"+port+"/calculate", bytes.NewBuffer(orderData))
+	req, err := http.NewRequestWithContext(ctx, "POST", host+":"+port+"/calculate", bytes.NewBuffer(orderData))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	// 4xx - заказ отклонен Engine, повтор не поможет; 5xx и ошибки соединения - временные
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("Engine service HTTP error: %s", resp.Status)
+		if !retryableStatus(resp.StatusCode) {
+			return nil, fmt.Errorf("%w: Engine service HTTP error: %s", model.ErrInvalidArgument, resp.Status)
+		}
+		return nil, fmt.Errorf("Engine service HTTP error: %s", resp.Status)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	calcResponse := &CalculateResponse{}
 	err = json.Unmarshal(body, calcResponse)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return calcResponse.Points, nil
+	// ответ без разбивки по кошелькам - все баллы в основной кошелек
+	if calcResponse.Wallets == nil && calcResponse.Points != 0 {
+		return map[string]int32{model.WALLET_BASE: calcResponse.Points}, nil
+	}
+	return calcResponse.Wallets, nil
+}
+
+// ошибки клиента не повторяются, кроме таймаута запроса и превышения лимита запросов
+func retryableStatus(code int) bool {
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return true
+	}
+	return code < 400 || code >= 500
 }
@@ -0,0 +1,45 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateOrderStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      int
+		retryable bool
+	}{
+		{"заказ отклонен", http.StatusBadRequest, false},
+		{"нет доступа", http.StatusForbidden, false},
+		{"превышен лимит запросов", http.StatusTooManyRequests, true},
+		{"ошибка Engine", http.StatusInternalServerError, true},
+		{"Engine недоступен", http.StatusServiceUnavailable, true},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(ts.code)
+			}))
+			defer server.Close()
+			u, err := url.Parse(server.URL)
+			require.NoError(t, err)
+			t.Setenv("ENGINE_HOST", "http://"+u.Hostname())
+			t.Setenv("ENGINE_PORT", u.Port())
+
+			_, err = CalculateOrder(context.Background(), `{"total":1,"items":[]}`)
+			require.Error(t, err)
+			// ErrInvalidArgument не повторяется (services.IsRetryable)
+			require.Equal(t, !ts.retryable, errors.Is(err, model.ErrInvalidArgument))
+		})
+	}
+}
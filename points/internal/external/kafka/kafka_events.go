@@ -0,0 +1,49 @@
+package points
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ожидание пачки перед отправкой: WriteMessages синхронный, с пачкой по умолчанию (1с) - одно сообщение в секунду
+const writerBatchTimeout = 5 * time.Millisecond
+
+// ключ сообщения - ID пользователя или заказа, сообщения с одним ключом попадают в одну партицию
+func newWriter(addr string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(addr),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: writerBatchTimeout,
+	}
+}
+
+type KafkaWriter struct {
+	writer *kafka.Writer
+}
+
+func NewWriter() (writer *KafkaWriter, err error) {
+	addr, err := brokerAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaWriter{newWriter(addr)}, nil
+}
+
+// топик задается для каждого сообщения
+func (k *KafkaWriter) Publish(ctx context.Context, topic string, key string, value []byte) error {
+	msg := kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: value,
+	}
+	injectTrace(ctx, &msg.Headers)
+	return k.writer.WriteMessages(ctx, msg)
+}
+
+func (k *KafkaWriter) Close() {
+	k.writer.Close()
+}
@@ -0,0 +1,51 @@
+package points
+
+import "sync"
+
+// Учет обработанных сообщений по партициям: сообщения обрабатываются параллельно,
+// а коммитится только непрерывный префикс обработанных offset, чтобы не потерять необработанные
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	inflight []int64        // offset в порядке получения
+	done     map[int64]bool // обработанные offset из inflight
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[int]*partitionOffsets)}
+}
+
+// сообщение получено и передано в обработку
+func (t *offsetTracker) track(partition int, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[partition]
+	// после ребалансировки партиция читается заново с последнего закоммиченного offset
+	if !ok || (len(p.inflight) > 0 && offset <= p.inflight[len(p.inflight)-1]) {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[partition] = p
+	}
+	p.inflight = append(p.inflight, offset)
+}
+
+// сообщение обработано, возвращает offset, который можно закоммитить
+func (t *offsetTracker) done(partition int, offset int64) (commit int64, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, exists := t.partitions[partition]
+	if !exists {
+		return 0, false
+	}
+	p.done[offset] = true
+	for len(p.inflight) > 0 && p.done[p.inflight[0]] {
+		commit, ok = p.inflight[0], true
+		delete(p.done, commit)
+		p.inflight = p.inflight[1:]
+	}
+	return commit, ok
+}
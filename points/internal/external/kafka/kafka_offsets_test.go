@@ -0,0 +1,41 @@
+package points
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+	for offset := int64(10); offset < 14; offset++ {
+		tracker.track(0, offset)
+	}
+	tracker.track(1, 5)
+
+	// 11 обработан раньше 10 - коммитить нельзя
+	_, ok := tracker.done(0, 11)
+	require.False(t, ok)
+
+	// после 10 коммитится непрерывный префикс 10, 11
+	commit, ok := tracker.done(0, 10)
+	require.True(t, ok)
+	require.Equal(t, int64(11), commit)
+
+	// партиции учитываются отдельно
+	commit, ok = tracker.done(1, 5)
+	require.True(t, ok)
+	require.Equal(t, int64(5), commit)
+
+	_, ok = tracker.done(0, 13)
+	require.False(t, ok)
+	commit, ok = tracker.done(0, 12)
+	require.True(t, ok)
+	require.Equal(t, int64(13), commit)
+
+	// повторное чтение после ребалансировки начинается заново
+	tracker.track(0, 12)
+	commit, ok = tracker.done(0, 12)
+	require.True(t, ok)
+	require.Equal(t, int64(12), commit)
+}
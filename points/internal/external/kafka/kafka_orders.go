@@ -2,43 +2,308 @@ package points
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
+	metrics "github.com/glkeru/loyalty/points/observability/metrics"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Топики повторов и недоставленных сообщений: <topic>.retry, <topic>.dlq
+const (
+	SUFFIX_RETRY = ".retry"
+	SUFFIX_DLQ   = ".dlq"
+)
+
+// Заголовки сообщений в топиках повторов и DLQ
+const (
+	HEADER_ATTEMPTS = "x-attempts"       // кол-во неудачных попыток обработки
+	HEADER_ERROR    = "x-error"          // последняя ошибка
+	HEADER_RETRY_AT = "x-retry-at"       // время следующей попытки, unix ms
+	HEADER_TOPIC    = "x-original-topic" // исходный топик
+)
+
+const groupId = "orders_loyalty"
+
+// Обработчик сообщения
+type Handler func(ctx context.Context, value string) error
+
 type KafkaOrder struct {
-	reader *kafka.Reader
+	topic     string
+	reader    *kafka.Reader // исходный топик
+	retry     *kafka.Reader // топик повторов
+	writer    *kafka.Writer // отправка в топики повторов и DLQ
+	retries   int           // кол-во повторов до отправки в DLQ
+	backoff   time.Duration // пауза перед первым повтором, удваивается
+	retryable func(error) bool
 }
 
-func GetNewReader(topic string) (reader *KafkaOrder, err error) {
-	// config
-	kafkaurl := os.Getenv("KAFKA_ORDER_URL")
-	if kafkaurl == "" {
-		return nil, fmt.Errorf("env KAFKA_ORDER_URL is not set")
+// retryable - ошибка временная, обработку можно повторить; остальные сообщения сразу отправляются в DLQ
+func GetNewReader(topic string, retryable func(error) bool) (reader *KafkaOrder, err error) {
+	addr, err := brokerAddr()
+	if err != nil {
+		return nil, err
 	}
-	kafkaport := os.Getenv("KAFKA_ORDER_PORT")
-	if kafkaurl == "" {
-		return nil, fmt.Errorf("env KAFKA_ORDER_PORT is not set")
+
+	// TODO DEFAULT
+	retries := 3
+	retriesenv := os.Getenv("POINTS_KAFKA_RETRIES")
+	if retriesenv != "" {
+		r, err := strconv.Atoi(retriesenv)
+		if err == nil && r >= 0 {
+			retries = r
+		}
+	}
+	// TODO DEFAULT
+	backoff := 1000
+	backoffenv := os.Getenv("POINTS_KAFKA_BACKOFF")
+	if backoffenv != "" {
+		b, err := strconv.Atoi(backoffenv)
+		if err == nil && b > 0 {
+			backoff = b
+		}
+	}
+
+	// offset коммитятся явно после обработки
+	newReader := func(topic string) *kafka.Reader {
+		return kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{addr},
+			Topic:   topic,
+			GroupID: groupId,
+		})
 	}
+	return &KafkaOrder{
+		topic:     topic,
+		reader:    newReader(topic),
+		retry:     newReader(topic + SUFFIX_RETRY),
+		writer:    newWriter(addr),
+		retries:   retries,
+		backoff:   time.Duration(backoff) * time.Millisecond,
+		retryable: retryable,
+	}, nil
+}
+
+// Чтение исходного топика и топика повторов до отмены ctx или ошибки отправки в топик повторов/DLQ
+// Сообщения исходного топика обрабатываются параллельно (workers), повторы - последовательно по времени
+func (k *KafkaOrder) Consume(ctx context.Context, workers int, handler Handler) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		k.consume(ctx, cancel, k.reader, workers, handler)
+	}()
+	go func() {
+		defer wg.Done()
+		k.consume(ctx, cancel, k.retry, 1, handler)
+	}()
+	wg.Wait()
+
+	err := context.Cause(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
 
-	kafkaconfig := kafka.ReaderConfig{
-		Brokers: []string{kafkaurl + ":" + kafkaport},
-		Topic:   topic,
-		GroupID: "orders_loyalty",
+func (k *KafkaOrder) consume(ctx context.Context, cancel context.CancelCauseFunc, reader *kafka.Reader, workers int, handler Handler) {
+	offsets := newOffsetTracker()
+	wg := &sync.WaitGroup{}
+	semaphore := make(chan struct{}, workers)
+	defer wg.Wait()
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				cancel(err)
+			}
+			return
+		}
+
+		// повтор - ждать наступления времени следующей попытки
+		if retryAt := header(msg, HEADER_RETRY_AT); retryAt != "" {
+			at, _ := strconv.ParseInt(retryAt, 10, 64)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(time.UnixMilli(at))):
+			}
+		}
+
+		metrics.MessagesConsumed.WithLabelValues(msg.Topic).Inc()
+		metrics.ConsumerLag.WithLabelValues(msg.Topic, strconv.Itoa(msg.Partition)).Set(float64(msg.HighWaterMark - msg.Offset - 1))
+		offsets.track(msg.Partition, msg.Offset)
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(msg kafka.Message) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			err := k.handle(ctx, msg, handler)
+			// остановка - сообщение не коммитится и будет прочитано повторно
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				err = k.fail(ctx, msg, err)
+				if err != nil {
+					cancel(err)
+					return
+				}
+			}
+			if offset, ok := offsets.done(msg.Partition, msg.Offset); ok {
+				commit := msg
+				commit.Offset = offset
+				err = reader.CommitMessages(ctx, commit)
+				if err != nil && ctx.Err() == nil {
+					cancel(err)
+				}
+			}
+		}(msg)
 	}
-	return &KafkaOrder{kafka.NewReader(kafkaconfig)}, nil
 }
 
-func (k *KafkaOrder) GetNewMessage(ctx context.Context) (orderJson string, err error) {
-	msg, err := k.reader.ReadMessage(ctx)
+// обработка сообщения в спане, продолжающем трассировку отправителя
+func (k *KafkaOrder) handle(ctx context.Context, msg kafka.Message, handler Handler) error {
+	start := time.Now()
+	ctx, span := otel.Tracer("points/kafka").Start(extractTrace(ctx, msg), msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int("messaging.kafka.partition", msg.Partition),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		))
+	defer span.End()
+
+	err := handler(ctx, string(msg.Value))
 	if err != nil {
-		return "", err
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
-	return string(msg.Value), nil
+	metrics.ObserveMessage(msg.Topic, start, err)
+	return err
+}
+
+// отправка сообщения с ошибкой в топик повторов или, если повторы исчерпаны, в DLQ
+func (k *KafkaOrder) fail(ctx context.Context, msg kafka.Message, failure error) error {
+	attempts, _ := strconv.Atoi(header(msg, HEADER_ATTEMPTS))
+	attempts++
+
+	topic := k.topic + SUFFIX_RETRY
+	if !k.retryable(failure) || attempts > k.retries {
+		topic = k.topic + SUFFIX_DLQ
+	}
+	retryAt := time.Now().Add(k.backoff << (attempts - 1))
+
+	retry := kafka.Message{
+		Topic: topic,
+		Key:   msg.Key,
+		Value: msg.Value,
+		Headers: []kafka.Header{
+			{Key: HEADER_ATTEMPTS, Value: []byte(strconv.Itoa(attempts))},
+			{Key: HEADER_ERROR, Value: []byte(failure.Error())},
+			{Key: HEADER_RETRY_AT, Value: []byte(strconv.FormatInt(retryAt.UnixMilli(), 10))},
+			{Key: HEADER_TOPIC, Value: []byte(k.topic)},
+		},
+	}
+	// повтор остается в трассировке исходного сообщения
+	injectTrace(extractTrace(ctx, msg), &retry.Headers)
+	return k.writer.WriteMessages(ctx, retry)
 }
 
 func (k *KafkaOrder) CloseReader() {
 	k.reader.Close()
+	k.retry.Close()
+	k.writer.Close()
+}
+
+// Повторная отправка сообщений из DLQ в исходный топик
+// Читает <topic>.dlq, пока не будет прочитано limit сообщений (0 - без ограничения) или новых сообщений нет дольше idle
+func Replay(ctx context.Context, topic string, limit int, idle time.Duration) (count int, err error) {
+	addr, err := brokerAddr()
+	if err != nil {
+		return 0, err
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{addr},
+		Topic:   topic + SUFFIX_DLQ,
+		GroupID: groupId + "_replay",
+	})
+	defer reader.Close()
+	writer := newWriter(addr)
+	writer.Topic = topic
+	defer writer.Close()
+
+	for limit == 0 || count < limit {
+		fetchctx, cancel := context.WithTimeout(ctx, idle)
+		msg, err := reader.FetchMessage(fetchctx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				return count, nil
+			}
+			return count, err
+		}
+
+		// счетчик попыток сбрасывается, сообщение обрабатывается как новое
+		replay := kafka.Message{Key: msg.Key, Value: msg.Value}
+		injectTrace(extractTrace(ctx, msg), &replay.Headers)
+		err = writer.WriteMessages(ctx, replay)
+		if err != nil {
+			return count, err
+		}
+		err = reader.CommitMessages(ctx, msg)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Проверка доступности брокера
+func Ping(ctx context.Context) error {
+	addr, err := brokerAddr()
+	if err != nil {
+		return err
+	}
+	conn, err := kafka.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// адрес брокера
+func brokerAddr() (addr string, err error) {
+	kafkaurl := os.Getenv("KAFKA_ORDER_URL")
+	if kafkaurl == "" {
+		return "", fmt.Errorf("env KAFKA_ORDER_URL is not set")
+	}
+	kafkaport := os.Getenv("KAFKA_ORDER_PORT")
+	if kafkaport == "" {
+		return "", fmt.Errorf("env KAFKA_ORDER_PORT is not set")
+	}
+	return kafkaurl + ":" + kafkaport, nil
 }
@@ -0,0 +1,40 @@
+package points
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracePropagation(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+
+	// служебные заголовки сохраняются, traceparent добавляется
+	msg := kafka.Message{Headers: []kafka.Header{{Key: HEADER_ATTEMPTS, Value: []byte("1")}}}
+	injectTrace(ctx, &msg.Headers)
+	require.Equal(t, "1", header(msg, HEADER_ATTEMPTS))
+	require.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", header(msg, "traceparent"))
+
+	// повторная запись заменяет заголовок
+	injectTrace(ctx, &msg.Headers)
+	require.Len(t, msg.Headers, 2)
+
+	span := trace.SpanContextFromContext(extractTrace(context.Background(), msg))
+	require.Equal(t, traceID, span.TraceID())
+	require.Equal(t, spanID, span.SpanID())
+}
@@ -0,0 +1,87 @@
+package points
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Отправка исходящих сообщений в очереди RabbitMQ с подтверждением от брокера
+type RabbitPublisher struct {
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	declared map[string]bool
+}
+
+func NewRabbitPublisher() (rabbit *RabbitPublisher, err error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// publisher confirms: сообщение считается отправленным после подтверждения брокером
+	err = ch.Confirm(false)
+	if err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, err
+	}
+	return &RabbitPublisher{conn, ch, make(map[string]bool)}, nil
+}
+
+func (r *RabbitPublisher) Close() {
+	r.ch.Close()
+	r.conn.Close()
+}
+
+// Проверка соединения с RabbitMQ
+func (r *RabbitPublisher) Ping(ctx context.Context) error {
+	return ping(r.conn)
+}
+
+// отправка сообщения в очередь, key - ID сообщения
+func (r *RabbitPublisher) Publish(ctx context.Context, queue string, key string, value []byte) error {
+	if !r.declared[queue] {
+		_, err := r.ch.QueueDeclare(
+			queue, // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			nil,   // arguments
+		)
+		if err != nil {
+			return err
+		}
+		r.declared[queue] = true
+	}
+
+	confirm, err := r.ch.PublishWithDeferredConfirmWithContext(ctx,
+		"",    // exchange
+		queue, // routing key
+		false, // mandatory
+		false, // immediate
+		amqp.Publishing{
+			Headers:      traceHeaders(ctx),
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    key,
+			Body:         value,
+		})
+	if err != nil {
+		return err
+	}
+	ack, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !ack {
+		return fmt.Errorf("message %s to queue %s is not confirmed", key, queue)
+	}
+	return nil
+}
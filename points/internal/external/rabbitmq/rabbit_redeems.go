@@ -2,9 +2,9 @@ package points
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -13,33 +13,36 @@ type RabbitConsumer struct {
 	conn  *amqp.Connection
 	ch    *amqp.Channel
 	Msg   <-chan amqp.Delivery
-	chout *amqp.Channel
+	chdlx *amqp.Channel
 }
 
-const queue = "redeems"
-const queueout = "confirms"
+// очередь операций списания: durable с dead-letter exchange
+// новое имя, т.к. очередь redeems предыдущей версии недолговечна и без аргументов -
+// объявление с другими параметрами на существующем брокере завершается PRECONDITION_FAILED
+const QUEUE_REDEEMS = "redeems.v2"
+
+const queue = QUEUE_REDEEMS
+const exchangedlx = "redeems.dlx"
+const queuedlx = "redeems.dead"
+
+// заголовки сообщения в dead-letter очереди
+const (
+	HEADER_REASON   = "x-failure-reason"
+	HEADER_ATTEMPTS = "x-failure-attempts"
+)
 
 func NewRabbitConsumer() (rabbit *RabbitConsumer, err error) {
-	// config
-	rabbiturl := os.Getenv("RABBIT_URL")
-	if rabbiturl == "" {
-		return nil, fmt.Errorf("env RABBIT_URL is not set")
-	}
-	rabbitport := os.Getenv("RABBIT_PORT")
-	if rabbiturl == "" {
-		return nil, fmt.Errorf("env RABBIT_PORT is not set")
-	}
-	rabbituser := os.Getenv("RABBIT_USER")
-	if rabbiturl == "" {
-		return nil, fmt.Errorf("env RABBIT_USER is not set")
-	}
-	rabbitpass := os.Getenv("RABBIT_PASSWORD")
-	if rabbiturl == "" {
-		return nil, fmt.Errorf("env RABBIT_PASSWORD is not set")
+	// TODO DEFAULT
+	prefetch := 10
+	prefetchenv := os.Getenv("POINTS_REDEEM_PREFETCH")
+	if prefetchenv != "" {
+		p, err := strconv.Atoi(prefetchenv)
+		if err == nil && p > 0 {
+			prefetch = p
+		}
 	}
 
-	rabbitconn := "amqp://" + rabbituser + ":" + rabbitpass + "@" + rabbiturl + ":" + rabbitport + "/points"
-	conn, err := amqp.Dial(rabbitconn)
+	conn, err := dial()
 	if err != nil {
 		return nil, err
 	}
@@ -49,33 +52,49 @@ func NewRabbitConsumer() (rabbit *RabbitConsumer, err error) {
 		conn.Close()
 		return nil, err
 	}
+
+	// dead-letter exchange и очередь для сообщений, которые не могут быть обработаны
+	err = ch.ExchangeDeclare(
+		exchangedlx, // name
+		"fanout",    // type
+		true,        // durable
+		false,       // auto-deleted
+		false,       // internal
+		false,       // no-wait
+		nil,         // arguments
+	)
+	if err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, err
+	}
 	_, err = ch.QueueDeclare(
-		queue, // name
-		false, // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
+		queuedlx, // name
+		true,     // durable
+		false,    // delete when unused
+		false,    // exclusive
+		false,    // no-wait
+		nil,      // arguments
 	)
 	if err != nil {
 		ch.Close()
 		conn.Close()
 		return nil, err
 	}
-
-	// канал для исходящих
-	chout, err := conn.Channel()
+	err = ch.QueueBind(queuedlx, "", exchangedlx, false, nil)
 	if err != nil {
+		ch.Close()
 		conn.Close()
 		return nil, err
 	}
-	_, err = chout.QueueDeclare(
-		queueout, // name
-		false,    // durable
-		false,    // delete when unused
-		false,    // exclusive
-		false,    // no-wait
-		nil,      // arguments
+
+	_, err = ch.QueueDeclare(
+		queue, // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{"x-dead-letter-exchange": exchangedlx}, // nack без requeue - в dead-letter
 	)
 	if err != nil {
 		ch.Close()
@@ -83,10 +102,18 @@ func NewRabbitConsumer() (rabbit *RabbitConsumer, err error) {
 		return nil, err
 	}
 
+	// не больше prefetch неподтвержденных сообщений на получателя
+	err = ch.Qos(prefetch, 0, false)
+	if err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, err
+	}
+
 	msg, err := ch.Consume(
 		queue, // queue
 		"",    // consumer
-		true,  // auto-ack
+		false, // auto-ack
 		false, // exclusive
 		false, // no-local
 		false, // no-wait
@@ -98,37 +125,120 @@ func NewRabbitConsumer() (rabbit *RabbitConsumer, err error) {
 		return nil, err
 	}
 
-	return &RabbitConsumer{conn, ch, msg, chout}, nil
+	// канал для отправки в dead-letter с подтверждением брокера
+	chdlx, err := conn.Channel()
+	if err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, err
+	}
+	err = chdlx.Confirm(false)
+	if err != nil {
+		chdlx.Close()
+		ch.Close()
+		conn.Close()
+		return nil, err
+	}
+
+	return &RabbitConsumer{conn, ch, msg, chdlx}, nil
 }
 func (r *RabbitConsumer) Close() {
+	r.chdlx.Close()
 	r.ch.Close()
 	r.conn.Close()
 }
 
-type RedeemConfirm struct {
-	RedeemId string
-	Success  bool
+// Проверка соединения с RabbitMQ
+func (r *RabbitConsumer) Ping(ctx context.Context) error {
+	return ping(r.conn)
 }
 
-// подтверждение списания
-func (r *RabbitConsumer) Processed(ctx context.Context, redeemId string, success bool) error {
-	st := &RedeemConfirm{redeemId, success}
-	msg, err := json.Marshal(st)
+// Кол-во сообщений в очереди, ожидающих обработки
+// отдельный канал: ошибка пассивного объявления закрывает канал
+func (r *RabbitConsumer) Depth() (int, error) {
+	ch, err := r.conn.Channel()
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer ch.Close()
+	q, err := ch.QueueDeclarePassive(
+		queue, // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{"x-dead-letter-exchange": exchangedlx}, // arguments
+	)
+	if err != nil {
+		return 0, err
+	}
+	return q.Messages, nil
+}
+
+// Отправка сообщения в dead-letter очередь с причиной ошибки, исходное сообщение подтверждается
+// Если отправка не удалась - nack без requeue, сообщение попадет в dead-letter exchange очереди без причины
+func (r *RabbitConsumer) DeadLetter(ctx context.Context, msg amqp.Delivery, reason string, attempts int) error {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
 	}
+	headers[HEADER_REASON] = reason
+	headers[HEADER_ATTEMPTS] = int32(attempts)
 
-	err = r.chout.PublishWithContext(ctx,
-		"",       // exchange
-		queueout, // routing key
-		false,    // mandatory
-		false,    // immediate
+	confirm, err := r.chdlx.PublishWithDeferredConfirmWithContext(ctx,
+		exchangedlx, // exchange
+		queue,       // routing key
+		false,       // mandatory
+		false,       // immediate
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        []byte(msg),
+			Headers:      headers,
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    msg.MessageId,
+			Timestamp:    msg.Timestamp,
+			Body:         msg.Body,
 		})
+	if err == nil {
+		var ack bool
+		ack, err = confirm.WaitContext(ctx)
+		if err == nil && !ack {
+			err = fmt.Errorf("dead-letter message is not confirmed")
+		}
+	}
 	if err != nil {
+		_ = msg.Nack(false, false)
 		return err
 	}
+	return msg.Ack(false)
+}
+
+func ping(conn *amqp.Connection) error {
+	if conn.IsClosed() {
+		return fmt.Errorf("rabbitmq connection is closed")
+	}
 	return nil
 }
+
+// подключение к RabbitMQ
+func dial() (conn *amqp.Connection, err error) {
+	// config
+	rabbiturl := os.Getenv("RABBIT_URL")
+	if rabbiturl == "" {
+		return nil, fmt.Errorf("env RABBIT_URL is not set")
+	}
+	rabbitport := os.Getenv("RABBIT_PORT")
+	if rabbiturl == "" {
+		return nil, fmt.Errorf("env RABBIT_PORT is not set")
+	}
+	rabbituser := os.Getenv("RABBIT_USER")
+	if rabbiturl == "" {
+		return nil, fmt.Errorf("env RABBIT_USER is not set")
+	}
+	rabbitpass := os.Getenv("RABBIT_PASSWORD")
+	if rabbiturl == "" {
+		return nil, fmt.Errorf("env RABBIT_PASSWORD is not set")
+	}
+
+	rabbitconn := "amqp://" + rabbituser + ":" + rabbitpass + "@" + rabbiturl + ":" + rabbitport + "/points"
+	return amqp.Dial(rabbitconn)
+}
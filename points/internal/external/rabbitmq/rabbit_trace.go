@@ -0,0 +1,40 @@
+package points
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+)
+
+// Заголовки сообщения RabbitMQ как носитель контекста трассировки (traceparent, baggage)
+type tableCarrier amqp.Table
+
+func (c tableCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c tableCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c tableCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Контекст трассировки отправителя из заголовков полученного сообщения
+func MessageContext(ctx context.Context, msg amqp.Delivery) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, tableCarrier(msg.Headers))
+}
+
+// заголовки с контекстом трассировки ctx
+func traceHeaders(ctx context.Context) amqp.Table {
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, tableCarrier(headers))
+	return headers
+}
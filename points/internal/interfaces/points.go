@@ -9,19 +9,41 @@ import (
 )
 
 type PointsStorage interface {
-	TnxCreate(ctx context.Context, tnx model.PointTransaction) error
-	UserCreate(ctx context.Context, userid string) (useruuid uuid.UUID, err error)
-	TnxDelete(ctx context.Context, orderId string) error
-	TnxCommitOnDate(ctx context.Context, date time.Time) error
-	Redeem(ctx context.Context, user string, points float64, redeemId string) (err error)
-	Transfer(ctx context.Context, userfrom string, userto string, points float64, transferId string) (err error)
-	GetBalance(ctx context.Context, user string) (points float64, err error)
+	TnxCreate(ctx context.Context, tnxs []model.PointTransaction) error
+	UserCreate(ctx context.Context, userid string, wallet string) (useruuid uuid.UUID, err error)
+	TnxReturn(ctx context.Context, orderId string) error
+	TnxCommitOnDate(ctx context.Context, date time.Time) (result model.CommitResult, err error)
+	Redeem(ctx context.Context, user string, wallet string, points float64, redeemId string, events ...model.OutboxMessage) (err error)
+	Transfer(ctx context.Context, userfrom string, userto string, wallet string, points float64, transferId string) (err error)
+	Adjust(ctx context.Context, user string, wallet string, points float64, adjustId string, reason string) (err error)
+	GetBalance(ctx context.Context, user string) (balance model.BalanceVersion, err error)
 	GetTnx(ctx context.Context, user string, from time.Time, to time.Time) (tnxs []model.PointTransaction, err error)
-	GetUserUUID(ctx context.Context, user string) (account uuid.UUID, err error)
+	ListTnx(ctx context.Context, filter model.TnxFilter) (tnxs []model.PointTransaction, err error)
+	GetUserUUID(ctx context.Context, user string, wallet string) (account uuid.UUID, err error)
+	Hold(ctx context.Context, user string, wallet string, points float64, redeemId string, expires time.Time, events ...model.OutboxMessage) (err error)
+	CaptureHold(ctx context.Context, redeemId string, events ...model.OutboxMessage) (hold model.PointHold, user string, err error)
+	ReleaseHold(ctx context.Context, redeemId string, events ...model.OutboxMessage) (user string, err error)
+	ReleaseExpiredHolds(ctx context.Context, date time.Time) (users []string, err error)
+	ExpirePoints(ctx context.Context, wallet string, date time.Time) (users []string, err error)
+	VerifyLedger(ctx context.Context) (drifts []model.LedgerDrift, err error)
+	TrialBalance(ctx context.Context) (balances []model.LedgerBalance, err error)
+	ReconcileBalances(ctx context.Context) (drifts []model.BalanceDrift, err error)
+	ReconcileBalance(ctx context.Context, account uuid.UUID, date time.Time) (drift model.BalanceDrift, err error)
+	GetTiers(ctx context.Context) (tiers []model.Tier, err error)
+	TierActivity(ctx context.Context, from time.Time, basis string) (activity []model.TierActivity, err error)
+	SetTier(ctx context.Context, account uuid.UUID, oldTier string, newTier string, activity float64, date time.Time, events ...model.OutboxMessage) (changed bool, err error)
+	GetRedeemRule(ctx context.Context, wallet string) (rule model.RedeemRule, err error)
+	OutboxAdd(ctx context.Context, msgs ...model.OutboxMessage) (err error)
+	OutboxProcess(ctx context.Context, limit int, maxAttempts int, send func(msg model.OutboxMessage) error) (sent int, err error)
+}
+
+type MessagePublisher interface {
+	Publish(ctx context.Context, topic string, key string, value []byte) error
 }
 
 type CacheStorage interface {
-	GetBalance(ctx context.Context, user string) (points float64, err error)
-	SetBalance(ctx context.Context, user string, points float64) (err error)
+	GetBalance(ctx context.Context, user string) (balance model.BalanceVersion, err error)
+	// запись только если версия новее сохраненной
+	SetBalance(ctx context.Context, user string, balance model.BalanceVersion) (stored bool, err error)
 	InvalidateBalance(ctx context.Context, user string) error
 }
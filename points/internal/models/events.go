@@ -0,0 +1,79 @@
+package points
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Версия формата PointsEvent, увеличивается при несовместимых изменениях
+const POINTS_EVENT_VERSION = 1
+
+// Типы событий движения баллов
+const (
+	EVENT_ACCRUAL  = "accrual"  // создано начисление по заказу, баллы ожидают зачисления
+	EVENT_RETURN   = "return"   // начисление по заказу отменено возвратом, зачисленные баллы списаны
+	EVENT_COMMIT   = "commit"   // баллы зачислены на баланс
+	EVENT_REDEEM   = "redeem"   // списание
+	EVENT_TRANSFER = "transfer" // перевод между пользователями
+	EVENT_ADJUST   = "adjust"   // ручная корректировка
+	EVENT_EXPIRY   = "expiry"   // сгорание баллов
+	EVENT_BREAKAGE = "breakage" // списание неиспользованных баллов
+	EVENT_OPENING  = "opening"  // входящий остаток
+)
+
+// Событие движения баллов, ключ сообщения - ID пользователя
+type PointsEvent struct {
+	Version       int       `json:"version"`
+	EventId       string    `json:"eventId"`
+	Type          string    `json:"type"`
+	UserId        string    `json:"userId"`
+	Wallet        string    `json:"wallet"`
+	Delta         float64   `json:"delta"`             // изменение баланса
+	Balance       float64   `json:"balance"`           // баланс кошелька после изменения
+	Pending       float64   `json:"pending,omitempty"` // изменение баллов, ожидающих зачисления (accrual, return)
+	CorrelationId string    `json:"correlationId"`     // ID заказа, списания, перевода, корректировки
+	OccurredAt    time.Time `json:"occurredAt"`
+}
+
+func NewPointsEvent(eventType string, userId string, wallet string, delta float64, balance float64, correlationId string) PointsEvent {
+	return PointsEvent{
+		Version:       POINTS_EVENT_VERSION,
+		EventId:       uuid.NewString(),
+		Type:          eventType,
+		UserId:        userId,
+		Wallet:        wallet,
+		Delta:         delta,
+		Balance:       balance,
+		CorrelationId: correlationId,
+		OccurredAt:    time.Now(),
+	}
+}
+
+// Тип события по типу проводки
+func EntryEventType(entryType int) string {
+	switch entryType {
+	case ENTRY_OPENING:
+		return EVENT_OPENING
+	case ENTRY_ACCRUAL:
+		return EVENT_COMMIT
+	case ENTRY_REDEEM:
+		return EVENT_REDEEM
+	case ENTRY_TRANSFER:
+		return EVENT_TRANSFER
+	case ENTRY_ADJUST:
+		return EVENT_ADJUST
+	case ENTRY_EXPIRY:
+		return EVENT_EXPIRY
+	case ENTRY_BREAKAGE:
+		return EVENT_BREAKAGE
+	case ENTRY_RETURN:
+		return EVENT_RETURN
+	}
+	return ""
+}
+
+// Исходящее сообщение с событием движения баллов
+func (e PointsEvent) Message() (msg OutboxMessage, err error) {
+	return NewOutboxMessage(OUTBOX_KAFKA, TOPIC_POINTS, e.UserId, e)
+}
@@ -0,0 +1,82 @@
+package points
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Системные счета плана счетов (ledger_accounts)
+var (
+	LEDGER_ISSUANCE   = uuid.MustParse("00000000-0000-0000-0000-000000000001") // выпуск баллов
+	LEDGER_REDEMPTION = uuid.MustParse("00000000-0000-0000-0000-000000000002") // погашение баллов
+	LEDGER_BREAKAGE   = uuid.MustParse("00000000-0000-0000-0000-000000000003") // неиспользованные баллы
+	LEDGER_EXPIRY     = uuid.MustParse("00000000-0000-0000-0000-000000000004") // сгорание баллов
+	LEDGER_ADJUSTMENT = uuid.MustParse("00000000-0000-0000-0000-000000000005") // ручные корректировки
+	LEDGER_OPENING    = uuid.MustParse("00000000-0000-0000-0000-000000000006") // входящие остатки
+)
+
+// системный счет или счет пользователя
+func IsSystemAccount(account uuid.UUID) bool {
+	switch account {
+	case LEDGER_ISSUANCE, LEDGER_REDEMPTION, LEDGER_BREAKAGE, LEDGER_EXPIRY, LEDGER_ADJUSTMENT, LEDGER_OPENING:
+		return true
+	}
+	return false
+}
+
+// Типы проводок
+const (
+	ENTRY_OPENING  = 0 // входящий остаток
+	ENTRY_ACCRUAL  = 1 // начисление баллов по заказам
+	ENTRY_REDEEM   = 2 // списание баллов
+	ENTRY_TRANSFER = 3 // перевод баллов между пользователями
+	ENTRY_ADJUST   = 4 // ручная корректировка
+	ENTRY_EXPIRY   = 5 // сгорание баллов
+	ENTRY_BREAKAGE = 6 // списание неиспользованных баллов
+	ENTRY_RETURN   = 7 // сторно начисления по возврату заказа
+)
+
+// Проводка - сумма строк всегда равна 0
+type JournalEntry struct {
+	UUID      uuid.UUID
+	EntryType int           // тип проводки
+	Reference string        // ID операции-основания
+	CreatedAt time.Time     // дата/время проводки
+	Lines     []JournalLine // строки проводки
+}
+
+// Строка проводки
+type JournalLine struct {
+	Account uuid.UUID // UUID счета пользователя или системного счета
+	Amount  float64   // > 0 - увеличение остатка счета, < 0 - уменьшение
+}
+
+// Проводка между двумя счетами: перенос points со счета from на счет to
+func NewJournalEntry(entryType int, reference string, from uuid.UUID, to uuid.UUID, points float64) JournalEntry {
+	return JournalEntry{
+		UUID:      uuid.New(),
+		EntryType: entryType,
+		Reference: reference,
+		CreatedAt: time.Now(),
+		Lines: []JournalLine{
+			{Account: from, Amount: -points},
+			{Account: to, Amount: points},
+		},
+	}
+}
+
+// Расхождение остатка счета с журналом проводок
+type LedgerDrift struct {
+	Account       uuid.UUID `json:"account"`
+	UserId        string    `json:"userId"`
+	Balance       float64   `json:"balance"`       // остаток accounts.balance
+	LedgerBalance float64   `json:"ledgerBalance"` // сумма строк проводок по счету
+}
+
+// Оборотно-сальдовая ведомость - остаток по счету
+type LedgerBalance struct {
+	Account uuid.UUID `json:"account"`
+	Code    string    `json:"code"` // код системного счета, USERS - сумма по счетам пользователей
+	Balance float64   `json:"balance"`
+}
@@ -0,0 +1,49 @@
+package points
+
+import (
+	"encoding/json"
+)
+
+// Получатели исходящих сообщений
+const (
+	OUTBOX_RABBITMQ = "rabbitmq" // topic - очередь RabbitMQ
+	OUTBOX_KAFKA    = "kafka"    // topic - топик Kafka
+)
+
+// Очереди и топики исходящих сообщений
+const (
+	QUEUE_CONFIRMS = "confirms"      // подтверждения списаний
+	TOPIC_TIERS    = "tiers"         // изменения уровня статуса
+	TOPIC_POINTS   = "points.events" // движения баллов
+)
+
+// Исходящее сообщение (transactional outbox)
+type OutboxMessage struct {
+	ID          int64
+	Destination string // получатель: rabbitmq, kafka
+	Topic       string // очередь или топик
+	Key         string // ключ сообщения, для Kafka - ключ партиционирования
+	Payload     []byte // JSON
+	Attempts    int    // кол-во неудачных попыток отправки
+}
+
+// Исходящее сообщение с телом в JSON
+func NewOutboxMessage(destination string, topic string, key string, payload any) (msg OutboxMessage, err error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return msg, err
+	}
+	return OutboxMessage{
+		Destination: destination,
+		Topic:       topic,
+		Key:         key,
+		Payload:     body,
+	}, nil
+}
+
+// Подтверждение обработки сообщения очереди списаний
+type RedeemConfirm struct {
+	RedeemId string
+	Type     string // тип обработанного сообщения: redeem, hold, capture, release
+	Success  bool
+}
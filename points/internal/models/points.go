@@ -7,31 +7,159 @@ import (
 	"github.com/google/uuid"
 )
 
-// Счет баллов
+// Счет баллов - кошелек пользователя, у пользователя по счету на каждый кошелек
 type PointAccount struct {
 	UUID    uuid.UUID
 	Balance float64 // баланс
 	UserId  string  // ID пользователя
+	Wallet  string  // кошелек
+}
+
+// Кошельки
+const (
+	WALLET_BASE  = "BASE"  // основные баллы
+	WALLET_PROMO = "PROMO" // промо-баллы, сгорают через POINTS_PROMO_EXPIRY_DAYS после зачисления
+	WALLET_MILES = "MILES" // мили партнеров
+)
+
+var Wallets = map[string]bool{
+	WALLET_BASE:  true,
+	WALLET_PROMO: true,
+	WALLET_MILES: true,
 }
 
 const (
 	ACCRUEL = 0
 	REDEEM  = 1
+	ADJUST  = 2 // ручная корректировка
+	EXPIRY  = 3 // сгорание баллов
+	RETURN  = 4 // сторно начисления по возврату заказа
 )
 
+// Коды причин ручной корректировки
+const (
+	REASON_GOODWILL   = "GOODWILL"   // компенсация клиенту
+	REASON_CORRECTION = "CORRECTION" // исправление ошибки начисления/списания
+	REASON_FRAUD      = "FRAUD"      // аннулирование мошеннических баллов
+	REASON_MIGRATION  = "MIGRATION"  // перенос баллов из другой системы
+)
+
+var AdjustReasons = map[string]bool{
+	REASON_GOODWILL:   true,
+	REASON_CORRECTION: true,
+	REASON_FRAUD:      true,
+	REASON_MIGRATION:  true,
+}
+
 // Транзакции
 type PointTransaction struct {
 	UUID         uuid.UUID
 	PointAccount uuid.UUID // UUID счета
+	Wallet       string    // кошелек счета
 	Points       float64   // кол-во баллов
 	CommitDate   time.Time // дата/время транзакции / дата в будущем, в которую начислить баллы
+	ExpiresAt    time.Time // дата/время сгорания начисленных баллов, пустая - баллы не сгорают
 	Commit       bool      // транзакция обработана
 	TypeTnx      int       // тип операции
 	OrderID      string    // ID заказа
 	TransferID   string    // ID операции перевода баллов
 	RedeemID     string    // ID операции списания баллов
+	AdjustID     string    // ID операции корректировки
+	Reason       string    // код причины корректировки
+}
+
+// Фильтр истории транзакций, пустые поля не ограничивают выборку
+type TnxFilter struct {
+	User     string
+	From     time.Time // дата транзакции с
+	To       time.Time // дата транзакции по
+	Types    []int     // типы операций
+	Wallet   string    // кошелек
+	OrderID  string    // ID заказа
+	RedeemID string    // ID операции списания баллов
+	After    *TnxCursor
+	Limit    int // кол-во транзакций
+}
+
+// Позиция в истории транзакций: последняя транзакция предыдущей страницы
+// история сортируется по дате транзакции и UUID в обратном порядке
+type TnxCursor struct {
+	CommitDate time.Time
+	UUID       uuid.UUID
+}
+
+// Расхождение баланса счета с суммой обработанных транзакций
+type BalanceDrift struct {
+	Account    uuid.UUID `json:"account"`
+	UserId     string    `json:"userId"`
+	Wallet     string    `json:"wallet"`
+	Balance    float64   `json:"balance"`    // accounts.balance
+	Expected   float64   `json:"expected"`   // баланс, рассчитанный по транзакциям
+	Ledger     float64   `json:"ledger"`     // сумма строк проводок по счету
+	Difference float64   `json:"difference"` // balance - expected
+}
+
+// Счет, на который зачислены баллы заданием начисления
+type CommittedAccount struct {
+	Account uuid.UUID
+	UserId  string
+	Wallet  string
+	Points  float64 // зачислено баллов
+	Balance float64 // баланс счета после зачисления
+}
+
+// Результат задания начисления
+type CommitResult struct {
+	Accounts    []CommittedAccount // счета, на которые зачислены баллы
+	Failed      int                // счета, обработка которых завершилась ошибкой
+	CacheFailed int                // пользователи, кэш баланса которых не удалось обновить
+}
+
+// пользователи, у которых изменился баланс
+func (r CommitResult) Users() []string {
+	seen := make(map[string]bool, len(r.Accounts))
+	var users []string
+	for _, a := range r.Accounts {
+		if !seen[a.UserId] {
+			seen[a.UserId] = true
+			users = append(users, a.UserId)
+		}
+	}
+	return users
+}
+
+// Балансы кошельков пользователя с версией - суммой версий счетов пользователя
+// NotFound - пользователь неизвестен (отрицательное кэширование)
+type BalanceVersion struct {
+	Balances map[string]float64
+	Tier     string // уровень статуса, хранится на счете основного кошелька
+	Version  int64
+	NotFound bool
+}
+
+// Статусы холда
+const (
+	HOLD_ACTIVE   = 0
+	HOLD_CAPTURED = 1
+	HOLD_RELEASED = 2
+)
+
+// Холд - резервирование баллов до подтверждения списания
+type PointHold struct {
+	UUID         uuid.UUID
+	PointAccount uuid.UUID // UUID счета
+	Wallet       string    // кошелек счета
+	Points       float64   // кол-во баллов
+	RedeemID     string    // ID операции списания баллов
+	ExpiresAt    time.Time // дата/время, после которой холд снимается автоматически
+	Status       int       // статус холда
 }
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound        = errors.New("not found")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrNotEnoughPoints = errors.New("not enough points")
+	ErrHoldNotActive   = errors.New("hold is not active")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrMalformed       = errors.New("malformed message") // сообщение очереди не может быть обработано
 )
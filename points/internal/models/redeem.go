@@ -0,0 +1,38 @@
+package points
+
+// Правила списания баллов кошелька
+type RedeemRule struct {
+	Wallet     string   // кошелек
+	Rate       float64  // стоимость одного балла в деньгах
+	MaxShare   float64  // максимальная доля заказа, оплачиваемая баллами, 0..1
+	MinPoints  float64  // минимальное кол-во баллов в одном списании
+	Categories []string // категории товаров, которые нельзя оплатить баллами
+}
+
+// исключена ли категория из оплаты баллами
+func (r RedeemRule) Excluded(category string) bool {
+	for _, c := range r.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Позиция корзины для расчета списания
+type BasketItem struct {
+	Sku      string  `json:"sku"`      // ID товара
+	Category string  `json:"category"` // категория товара
+	Price    float64 `json:"price"`    // цена за единицу
+	Quantity float64 `json:"quantity"` // кол-во, 0 - одна единица
+}
+
+// Расчет максимального списания по корзине
+type RedeemQuote struct {
+	Wallet   string
+	Points   float64 // максимальное кол-во баллов, которое можно списать
+	Amount   float64 // сумма оплаты баллами
+	Eligible float64 // сумма позиций корзины, которые можно оплатить баллами
+	Balance  float64 // доступный баланс кошелька
+	Rate     float64 // стоимость одного балла
+}
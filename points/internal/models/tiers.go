@@ -0,0 +1,39 @@
+package points
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Уровень статуса клиента
+type Tier struct {
+	Code      string  // код уровня
+	Name      string  // наименование
+	Threshold float64 // активность за период, необходимая для уровня
+	Rank      int     // порядок уровня, больше - выше
+}
+
+// База расчета активности для уровня
+const (
+	TIER_BASIS_EARNED = "earned" // начисленные баллы
+	TIER_BASIS_SPENT  = "spent"  // списанные баллы
+)
+
+// Активность счета за период
+type TierActivity struct {
+	Account       uuid.UUID
+	UserId        string
+	Tier          string    // текущий уровень
+	TierChangedAt time.Time // дата/время присвоения текущего уровня
+	Activity      float64   // баллы за период
+}
+
+// Событие изменения уровня
+type TierChangedEvent struct {
+	UserId    string    `json:"userId"`
+	OldTier   string    `json:"oldTier"`
+	NewTier   string    `json:"newTier"`
+	Activity  float64   `json:"activity"`
+	ChangedAt time.Time `json:"changedAt"`
+}
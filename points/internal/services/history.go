@@ -0,0 +1,94 @@
+package points
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/google/uuid"
+)
+
+// размер страницы истории транзакций
+const (
+	tnxPageDefault = 50
+	tnxPageMax     = 500
+)
+
+// Страница истории обработанных транзакций, pageToken - курсор предыдущей страницы (пустой - первая страница)
+// next - курсор следующей страницы, пустой - страница последняя
+func (p *PointsService) ListTransactions(ctx context.Context, filter model.TnxFilter, pageToken string) (tnxs []model.PointTransaction, next string, err error) {
+	if filter.User == "" {
+		return nil, "", fmt.Errorf("%w: user is required", model.ErrInvalidArgument)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return nil, "", fmt.Errorf("%w: from is after to", model.ErrInvalidArgument)
+	}
+	for _, t := range filter.Types {
+		if t < model.ACCRUEL || t > model.RETURN {
+			return nil, "", fmt.Errorf("%w: unknown transaction type %d", model.ErrInvalidArgument, t)
+		}
+	}
+	if filter.Wallet != "" {
+		filter.Wallet, err = walletCode(filter.Wallet)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if filter.Limit < 0 {
+		return nil, "", fmt.Errorf("%w: page size must not be negative", model.ErrInvalidArgument)
+	}
+	limit := min(filter.Limit, tnxPageMax)
+	if limit == 0 {
+		limit = tnxPageDefault
+	}
+	if pageToken != "" {
+		cursor, err := decodeCursor(pageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		filter.After = &cursor
+	}
+
+	// на одну транзакцию больше - признак следующей страницы
+	filter.Limit = limit + 1
+	tnxs, err = p.db.ListTnx(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tnxs) > limit {
+		tnxs = tnxs[:limit]
+		last := tnxs[limit-1]
+		next = encodeCursor(model.TnxCursor{CommitDate: last.CommitDate, UUID: last.UUID})
+	}
+	return tnxs, next, nil
+}
+
+// курсор: "<дата транзакции, unix ns>.<UUID>" в base64url
+func encodeCursor(cursor model.TnxCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(cursor.CommitDate.UnixNano(), 10) + "." + cursor.UUID.String()))
+}
+
+func decodeCursor(token string) (cursor model.TnxCursor, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: invalid page token", model.ErrInvalidArgument)
+	}
+	date, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return cursor, fmt.Errorf("%w: invalid page token", model.ErrInvalidArgument)
+	}
+	nanos, err := strconv.ParseInt(date, 10, 64)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: invalid page token", model.ErrInvalidArgument)
+	}
+	cursor.UUID, err = uuid.Parse(id)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: invalid page token", model.ErrInvalidArgument)
+	}
+	cursor.CommitDate = time.Unix(0, nanos)
+	return cursor, nil
+}
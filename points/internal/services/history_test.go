@@ -0,0 +1,126 @@
+package points
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// хранилище истории транзакций, отсортированной по дате и UUID в обратном порядке
+type historyStorage struct {
+	interf.PointsStorage
+	tnxs    []model.PointTransaction
+	filters []model.TnxFilter
+}
+
+func (s *historyStorage) ListTnx(ctx context.Context, filter model.TnxFilter) ([]model.PointTransaction, error) {
+	s.filters = append(s.filters, filter)
+	var page []model.PointTransaction
+	for _, tnx := range s.tnxs {
+		if filter.After != nil {
+			after := tnx.CommitDate.Before(filter.After.CommitDate) ||
+				(tnx.CommitDate.Equal(filter.After.CommitDate) && tnx.UUID.String() < filter.After.UUID.String())
+			if !after {
+				continue
+			}
+		}
+		if len(page) == filter.Limit {
+			break
+		}
+		page = append(page, tnx)
+	}
+	return page, nil
+}
+
+func TestListTransactionsPages(t *testing.T) {
+	date := time.Date(2026, 10, 1, 12, 0, 0, 123456000, time.UTC)
+	storage := &historyStorage{}
+	// две транзакции с одной датой - порядок по UUID
+	for i, id := range []string{
+		"00000000-0000-0000-0000-000000000005",
+		"00000000-0000-0000-0000-000000000004",
+		"00000000-0000-0000-0000-000000000003",
+		"00000000-0000-0000-0000-000000000002",
+		"00000000-0000-0000-0000-000000000001",
+	} {
+		storage.tnxs = append(storage.tnxs, model.PointTransaction{
+			UUID:       uuid.MustParse(id),
+			CommitDate: date.Add(-time.Duration(i/2) * time.Hour),
+			Points:     float64(i + 1),
+		})
+	}
+	serv := NewPointService(zap.NewNop(), storage, nil)
+
+	var points []float64
+	token := ""
+	pages := 0
+	for {
+		tnxs, next, err := serv.ListTransactions(context.Background(), model.TnxFilter{User: "u1", Limit: 2}, token)
+		require.NoError(t, err)
+		for _, tnx := range tnxs {
+			points = append(points, tnx.Points)
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	require.Equal(t, []float64{1, 2, 3, 4, 5}, points)
+	require.Equal(t, 3, pages)
+	// из хранилища запрашивается на одну транзакцию больше размера страницы
+	require.Equal(t, 3, storage.filters[0].Limit)
+}
+
+func TestListTransactionsPageSize(t *testing.T) {
+	storage := &historyStorage{}
+	serv := NewPointService(zap.NewNop(), storage, nil)
+
+	_, _, err := serv.ListTransactions(context.Background(), model.TnxFilter{User: "u1"}, "")
+	require.NoError(t, err)
+	_, _, err = serv.ListTransactions(context.Background(), model.TnxFilter{User: "u1", Limit: 10000}, "")
+	require.NoError(t, err)
+	require.Equal(t, tnxPageDefault+1, storage.filters[0].Limit)
+	require.Equal(t, tnxPageMax+1, storage.filters[1].Limit)
+}
+
+func TestListTransactionsInvalid(t *testing.T) {
+	serv := NewPointService(zap.NewNop(), &historyStorage{}, nil)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		filter model.TnxFilter
+		token  string
+	}{
+		{"нет пользователя", model.TnxFilter{}, ""},
+		{"дата с позже даты по", model.TnxFilter{User: "u1", From: now, To: now.Add(-time.Hour)}, ""},
+		{"неизвестный тип", model.TnxFilter{User: "u1", Types: []int{9}}, ""},
+		{"неизвестный кошелек", model.TnxFilter{User: "u1", Wallet: "GOLD"}, ""},
+		{"отрицательный размер страницы", model.TnxFilter{User: "u1", Limit: -1}, ""},
+		{"невалидный курсор", model.TnxFilter{User: "u1"}, "not-a-cursor"},
+	}
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			_, _, err := serv.ListTransactions(context.Background(), ts.filter, ts.token)
+			require.ErrorIs(t, err, model.ErrInvalidArgument)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	cursor := model.TnxCursor{
+		CommitDate: time.Date(2026, 10, 1, 12, 0, 0, 123456000, time.UTC),
+		UUID:       uuid.New(),
+	}
+	decoded, err := decodeCursor(encodeCursor(cursor))
+	require.NoError(t, err)
+	require.True(t, cursor.CommitDate.Equal(decoded.CommitDate))
+	require.Equal(t, cursor.UUID, decoded.UUID)
+}
@@ -0,0 +1,80 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"go.uber.org/zap"
+)
+
+// Relay - отправка сообщений из outbox получателям (RabbitMQ, Kafka)
+type OutboxRelay struct {
+	logger     *zap.Logger
+	db         interf.PointsStorage
+	publishers map[string]interf.MessagePublisher // получатель -> publisher
+	batch      int
+	attempts   int // попыток отправки сообщения, после - сообщение откладывается
+}
+
+func NewOutboxRelay(logger *zap.Logger, db interf.PointsStorage, publishers map[string]interf.MessagePublisher) (relay *OutboxRelay) {
+	// TODO DEFAULT
+	batch := 100
+	batchenv := os.Getenv("POINTS_OUTBOX_BATCH")
+	if batchenv != "" {
+		b, err := strconv.Atoi(batchenv)
+		if err == nil && b > 0 {
+			batch = b
+		}
+	}
+	// TODO DEFAULT
+	attempts := 10
+	attemptsenv := os.Getenv("POINTS_OUTBOX_MAX_ATTEMPTS")
+	if attemptsenv != "" {
+		a, err := strconv.Atoi(attemptsenv)
+		if err == nil && a > 0 {
+			attempts = a
+		}
+	}
+	return &OutboxRelay{logger, db, publishers, batch, attempts}
+}
+
+// Интервал опроса outbox
+func (o *OutboxRelay) Interval() time.Duration {
+	// TODO DEFAULT
+	interval := 1000
+	intervalenv := os.Getenv("POINTS_OUTBOX_INTERVAL")
+	if intervalenv != "" {
+		i, err := strconv.Atoi(intervalenv)
+		if err == nil && i > 0 {
+			interval = i
+		}
+	}
+	return time.Duration(interval) * time.Millisecond
+}
+
+// Отправка пачки сообщений, возвращает кол-во отправленных
+func (o *OutboxRelay) Relay(ctx context.Context) (sent int, err error) {
+	return o.db.OutboxProcess(ctx, o.batch, o.attempts, func(msg model.OutboxMessage) error {
+		publisher, ok := o.publishers[msg.Destination]
+		if !ok {
+			return fmt.Errorf("unknown outbox destination %s", msg.Destination)
+		}
+		return publisher.Publish(ctx, msg.Topic, msg.Key, msg.Payload)
+	})
+}
+
+// Отправка всех накопленных сообщений
+func (o *OutboxRelay) RelayAll(ctx context.Context) (sent int, err error) {
+	for {
+		n, err := o.Relay(ctx)
+		sent += n
+		if err != nil || n < o.batch {
+			return sent, err
+		}
+	}
+}
@@ -3,42 +3,58 @@ package points
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"time"
 
+	contracts "github.com/glkeru/loyalty/contracts"
 	external "github.com/glkeru/loyalty/points/internal/external/engine"
 	interf "github.com/glkeru/loyalty/points/internal/interfaces"
 	model "github.com/glkeru/loyalty/points/internal/models"
+	metrics "github.com/glkeru/loyalty/points/observability/metrics"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 )
 
 type PointsService struct {
 	logger *zap.Logger
 	db     interf.PointsStorage
 	cache  interf.CacheStorage
+	loads  singleflight.Group // одновременные промахи кэша по пользователю читают БД один раз
 }
 
 func NewPointService(logger *zap.Logger, db interf.PointsStorage, cache interf.CacheStorage) (service *PointsService) {
-	return &PointsService{logger, db, cache}
+	return &PointsService{logger: logger, db: db, cache: cache}
 }
 
 // Расчет баллов по заказу
 func (p *PointsService) OrderCalculate(ctx context.Context, order string) error {
-	// рассчет баллов
-	points, err := external.CalculateOrder(ctx, order)
+	// проверка контракта сообщения
+	err := contracts.Validate(contracts.ORDER, []byte(order))
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", model.ErrMalformed, err)
 	}
 	// получить userId и orderId из переданного заказа
 	userId, orderId, err := GetUserAndOrder(order)
 	if err != nil {
 		return err
 	}
-	// сохранить транзакцию начисления
-	err = p.TnxOrderAccruelCreate(ctx, userId, float64(points), orderId)
+	// рассчет баллов по кошелькам
+	wallets, err := external.CalculateOrder(ctx, order)
+	if err != nil {
+		return err
+	}
+	// сохранить транзакции начисления
+	err = p.TnxOrderAccruelCreate(ctx, userId, wallets, orderId)
 	if err != nil {
+		// повторная доставка заказа - начисление уже создано
+		if errors.Is(err, model.ErrAlreadyExists) {
+			p.logger.Warn("Order is already processed", zap.String("order", orderId))
+			return nil
+		}
 		return err
 	}
 	return nil
@@ -46,6 +62,11 @@ func (p *PointsService) OrderCalculate(ctx context.Context, order string) error
 
 // Обработка возврата
 func (p *PointsService) ReturnProcess(ctx context.Context, order string) error {
+	// проверка контракта сообщения
+	err := contracts.Validate(contracts.RETURN, []byte(order))
+	if err != nil {
+		return fmt.Errorf("%w: %w", model.ErrMalformed, err)
+	}
 	// получить orderId из переданного заказа
 	_, orderId, err := GetUserAndOrder(order)
 	if err != nil {
@@ -54,8 +75,8 @@ func (p *PointsService) ReturnProcess(ctx context.Context, order string) error {
 
 	p.logger.Info("return",
 		zap.String("id", order))
-	// удалить транзакцию начисления
-	err = p.TnxDelete(ctx, orderId)
+	// отменить начисление по заказу
+	err = p.TnxReturn(ctx, orderId)
 	if err != nil {
 		return err
 	}
@@ -71,38 +92,44 @@ func GetUserAndOrder(orderJson string) (userId string, orderId string, err error
 	orderParams := &OrderStruct{}
 	err = json.Unmarshal([]byte(orderJson), orderParams)
 	if err != nil {
-		return
+		return "", "", fmt.Errorf("%w: %v", model.ErrMalformed, err)
 	}
 
 	userId = orderParams.UserId
 	if userId == "" {
-		return "", "", fmt.Errorf("Invalid order: userId field is required")
+		return "", "", fmt.Errorf("%w: Invalid order: userId field is required", model.ErrMalformed)
 	}
 
 	orderId = orderParams.OrderId
 	if orderId == "" {
-		return "", "", fmt.Errorf("Invalid order: orderId field is required")
+		return "", "", fmt.Errorf("%w: Invalid order: orderId field is required", model.ErrMalformed)
 	}
 	return
 }
 
-// запуск активации баллов на дату
-// TODO: вернуть пользователей, чтобы инвлидировать кэш балансов
-func (p *PointsService) CommitOnDate(ctx context.Context) error {
+// запуск активации баллов на дату, кэш балансов пользователей, которым зачислены баллы, обновляется
+func (p *PointsService) CommitOnDate(ctx context.Context) (result model.CommitResult, err error) {
 	date := time.Now()
-	//TODO: инвалидировать кэш балансов, которые поменялись
-	err := p.db.TnxCommitOnDate(ctx, date)
+	result, err = p.db.TnxCommitOnDate(ctx, date)
 	if err != nil {
-		return err
+		return result, err
 	}
-	return nil
+	for _, a := range result.Accounts {
+		metrics.PointsAccrued.WithLabelValues(a.Wallet).Add(a.Points)
+	}
+	// кэш обновляется из БД, а не балансами задания: баланс мог измениться после коммита задания
+	for _, user := range result.Users() {
+		err = p.RefreshBalance(ctx, user)
+		if err != nil {
+			p.logger.Error("Refresh balance error", zap.Error(err), zap.String("user", user))
+			result.CacheFailed++
+		}
+	}
+	return result, nil
 }
 
-// создание транзакции начисления
-func (p *PointsService) TnxOrderAccruelCreate(ctx context.Context, userId string, points float64, orderId string) error {
-	tnx := model.PointTransaction{}
-	tnx.Points = points
-
+// создание транзакций начисления по заказу, по транзакции на кошелек
+func (p *PointsService) TnxOrderAccruelCreate(ctx context.Context, userId string, wallets map[string]int32, orderId string) error {
 	// TODO DEFAULT
 	var dayscount int
 	var err error
@@ -115,59 +142,198 @@ func (p *PointsService) TnxOrderAccruelCreate(ctx context.Context, userId string
 			dayscount = 0
 		}
 	}
-	tnx.CommitDate = time.Now().Add(time.Duration(dayscount) * 24 * time.Hour)
-	tnx.TypeTnx = model.ACCRUEL
-	tnx.OrderID = orderId
-	account, err := p.db.GetUserUUID(ctx, userId)
+	commitDate := time.Now().Add(time.Duration(dayscount) * 24 * time.Hour)
+
+	tnxs, err := accrualTnxs(wallets, orderId, commitDate, promoExpiry())
 	if err != nil {
 		return err
 	}
-	tnx.PointAccount = account
-	err = p.db.TnxCreate(ctx, tnx)
+	// по заказу баллы не начислены
+	if len(tnxs) == 0 {
+		return nil
+	}
+	for i := range tnxs {
+		tnxs[i].PointAccount, err = p.db.GetUserUUID(ctx, userId, tnxs[i].Wallet)
+		if err != nil {
+			return err
+		}
+	}
+	err = p.db.TnxCreate(ctx, tnxs)
 	if err != nil {
 		return err
 	}
+	// новый счет меняет версию баланса и снимает отрицательное кэширование
+	err = p.RefreshBalance(ctx, userId)
+	if err != nil {
+		p.logger.Error(err.Error())
+	}
 	return nil
 }
 
-// удаление транзакции
-func (p *PointsService) TnxDelete(ctx context.Context, orderId string) error {
-	err := p.db.TnxDelete(ctx, orderId)
+// транзакции начисления по кошелькам, кошельки без баллов пропускаются
+// промо-баллы сгорают через expiry после зачисления
+func accrualTnxs(wallets map[string]int32, orderId string, commitDate time.Time, expiry time.Duration) (tnxs []model.PointTransaction, err error) {
+	for wallet, points := range wallets {
+		if !model.Wallets[wallet] {
+			return nil, fmt.Errorf("%w: unknown wallet %s", model.ErrInvalidArgument, wallet)
+		}
+		if points == 0 {
+			continue
+		}
+		tnx := model.PointTransaction{
+			Wallet:     wallet,
+			Points:     float64(points),
+			CommitDate: commitDate,
+			TypeTnx:    model.ACCRUEL,
+			OrderID:    orderId,
+		}
+		if wallet == model.WALLET_PROMO {
+			tnx.ExpiresAt = commitDate.Add(expiry)
+		}
+		tnxs = append(tnxs, tnx)
+	}
+	return tnxs, nil
+}
+
+// срок действия промо-баллов
+func promoExpiry() time.Duration {
+	// TODO DEFAULT
+	days, err := strconv.Atoi(os.Getenv("POINTS_PROMO_EXPIRY_DAYS"))
+	if err != nil || days <= 0 {
+		days = 90
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// кошелек операции, по умолчанию основной
+func walletCode(wallet string) (string, error) {
+	if wallet == "" {
+		return model.WALLET_BASE, nil
+	}
+	if !model.Wallets[wallet] {
+		return "", fmt.Errorf("%w: unknown wallet %s", model.ErrInvalidArgument, wallet)
+	}
+	return wallet, nil
+}
+
+// отмена начисления по возврату заказа
+func (p *PointsService) TnxReturn(ctx context.Context, orderId string) error {
+	err := p.db.TnxReturn(ctx, orderId)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// создание транзакции списания
+// типы сообщений очереди списаний
+const (
+	REDEEM_MSG  = "redeem"  // списание
+	HOLD_MSG    = "hold"    // резервирование баллов
+	CAPTURE_MSG = "capture" // подтверждение резерва
+	RELEASE_MSG = "release" // снятие резерва
+)
+
+// сообщение очереди списаний
 type RedeemStruct struct {
-	UserId   string  `json:"userId"`
-	Points   float64 `json:"points"`
-	RedeemId string  `json:"redeemId"`
+	Type     string             `json:"type"` // тип сообщения, по умолчанию списание
+	UserId   string             `json:"userId"`
+	Wallet   string             `json:"wallet"` // кошелек, по умолчанию основной
+	Points   float64            `json:"points"`
+	RedeemId string             `json:"redeemId"`
+	Ttl      int64              `json:"ttl"`   // время жизни резерва, сек
+	Items    []model.BasketItem `json:"items"` // корзина, оплачиваемая баллами
 }
 
-// cписание
-func (p *PointsService) Redeem(ctx context.Context, redeemJson string) (redeemId string, err error) {
-	redeem := &RedeemStruct{}
+// cписание: обработка сообщения очереди списаний
+func (p *PointsService) Redeem(ctx context.Context, redeemJson string) (redeem *RedeemStruct, err error) {
+	redeem = &RedeemStruct{}
+	// проверка контракта сообщения
+	err = contracts.Validate(contracts.REDEEM, []byte(redeemJson))
+	if err != nil {
+		// ID операции для журнала, если его удалось прочитать
+		_ = json.Unmarshal([]byte(redeemJson), redeem)
+		return redeem, fmt.Errorf("%w: %w", model.ErrMalformed, err)
+	}
 	err = json.Unmarshal([]byte(redeemJson), redeem)
 	if err != nil {
-		return "", err
+		return redeem, fmt.Errorf("%w: %v", model.ErrMalformed, err)
+	}
+	if redeem.RedeemId == "" {
+		return redeem, fmt.Errorf("%w: redeemId is required", model.ErrMalformed)
+	}
+	if redeem.Type == "" {
+		redeem.Type = REDEEM_MSG
+	}
+	// подтверждение записывается в outbox в одной транзакции с операцией
+	confirm, err := model.NewOutboxMessage(model.OUTBOX_RABBITMQ, model.QUEUE_CONFIRMS, redeem.RedeemId,
+		model.RedeemConfirm{RedeemId: redeem.RedeemId, Type: redeem.Type, Success: true})
+	if err != nil {
+		return redeem, err
+	}
+	switch redeem.Type {
+	case REDEEM_MSG:
+		err = p.TnxRedeemCreate(ctx, redeem.UserId, redeem.Wallet, redeem.Points, redeem.RedeemId, redeem.Items, confirm)
+	case HOLD_MSG:
+		_, err = p.Hold(ctx, redeem.UserId, redeem.Wallet, redeem.Points, redeem.RedeemId, time.Duration(redeem.Ttl)*time.Second, redeem.Items, confirm)
+	case CAPTURE_MSG:
+		err = p.CaptureHold(ctx, redeem.RedeemId, confirm)
+	case RELEASE_MSG:
+		err = p.ReleaseHold(ctx, redeem.RedeemId, confirm)
+	default:
+		err = fmt.Errorf("%w: unknown message type %s", model.ErrMalformed, redeem.Type)
 	}
-	err = p.TnxRedeemCreate(ctx, redeem.UserId, redeem.Points, redeem.RedeemId)
+	return redeem, err
+}
+
+// Временная ошибка (БД недоступна и т.п.) - обработку сообщения можно повторить
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, model.ErrMalformed),
+		errors.Is(err, model.ErrInvalidArgument),
+		errors.Is(err, model.ErrNotFound),
+		errors.Is(err, model.ErrAlreadyExists),
+		errors.Is(err, model.ErrNotEnoughPoints),
+		errors.Is(err, model.ErrHoldNotActive):
+		return false
+	}
+	return true
+}
+
+// подтверждение неуспешной обработки сообщения очереди списаний
+func (p *PointsService) RedeemFailed(ctx context.Context, redeem *RedeemStruct) error {
+	confirm, err := model.NewOutboxMessage(model.OUTBOX_RABBITMQ, model.QUEUE_CONFIRMS, redeem.RedeemId,
+		model.RedeemConfirm{RedeemId: redeem.RedeemId, Type: redeem.Type, Success: false})
 	if err != nil {
-		return redeem.RedeemId, err
+		return err
 	}
-	return redeem.RedeemId, nil
+	return p.db.OutboxAdd(ctx, confirm)
 }
 
-// создание транзакции списания
-func (p *PointsService) TnxRedeemCreate(ctx context.Context, userId string, points float64, redeemId string) error {
-	err := p.db.Redeem(ctx, userId, points, redeemId)
+// создание транзакции списания из кошелька в оплату корзины
+func (p *PointsService) TnxRedeemCreate(ctx context.Context, userId string, wallet string, points float64, redeemId string, items []model.BasketItem, events ...model.OutboxMessage) error {
+	if points <= 0 {
+		return fmt.Errorf("%w: points must be positive", model.ErrInvalidArgument)
+	}
+	if redeemId == "" {
+		return fmt.Errorf("%w: redeemId is required", model.ErrInvalidArgument)
+	}
+	wallet, err := walletCode(wallet)
+	if err != nil {
+		return err
+	}
+	err = p.checkRedeemRule(ctx, wallet, points, items)
 	if err != nil {
 		return err
 	}
+	err = p.db.Redeem(ctx, userId, wallet, points, redeemId, events...)
+	if err != nil {
+		return err
+	}
+	metrics.PointsRedeemed.WithLabelValues(wallet).Add(points)
 	if p.cache != nil {
-		err = p.InvalidateBalance(ctx, userId)
+		err = p.RefreshBalance(ctx, userId)
 		if err != nil {
 			p.logger.Error(err.Error())
 		}
@@ -176,19 +342,58 @@ func (p *PointsService) TnxRedeemCreate(ctx context.Context, userId string, poin
 	return nil
 }
 
-// перевод баллов
-func (p *PointsService) Transfer(ctx context.Context, userfrom string, userto string, points float64, transferId string) error {
-	err := p.db.Transfer(ctx, userfrom, userto, points, transferId)
+// резервирование баллов кошелька в оплату корзины, ttl = 0 - время жизни по умолчанию
+func (p *PointsService) Hold(ctx context.Context, userId string, wallet string, points float64, redeemId string, ttl time.Duration, items []model.BasketItem, events ...model.OutboxMessage) (expires time.Time, err error) {
+	if points <= 0 {
+		return time.Time{}, fmt.Errorf("%w: points must be positive", model.ErrInvalidArgument)
+	}
+	if redeemId == "" {
+		return time.Time{}, fmt.Errorf("%w: redeemId is required", model.ErrInvalidArgument)
+	}
+	wallet, err = walletCode(wallet)
 	if err != nil {
-		return err
+		return time.Time{}, err
+	}
+	err = p.checkRedeemRule(ctx, wallet, points, items)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ttl <= 0 {
+		ttl = holdTTL()
+	}
+	expires = time.Now().Add(ttl)
+	err = p.db.Hold(ctx, userId, wallet, points, redeemId, expires, events...)
+	if err != nil {
+		return time.Time{}, err
 	}
-
 	if p.cache != nil {
-		err = p.InvalidateBalance(ctx, userfrom)
+		err = p.RefreshBalance(ctx, userId)
 		if err != nil {
 			p.logger.Error(err.Error())
 		}
-		err = p.InvalidateBalance(ctx, userto)
+	}
+	return expires, nil
+}
+
+// время жизни резерва по умолчанию
+func holdTTL() time.Duration {
+	// TODO DEFAULT
+	ttl, err := strconv.Atoi(os.Getenv("POINTS_HOLD_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = 900
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// подтверждение резерва - окончательное списание
+func (p *PointsService) CaptureHold(ctx context.Context, redeemId string, events ...model.OutboxMessage) error {
+	hold, user, err := p.db.CaptureHold(ctx, redeemId, events...)
+	if err != nil {
+		return err
+	}
+	metrics.PointsRedeemed.WithLabelValues(hold.Wallet).Add(hold.Points)
+	if p.cache != nil {
+		err = p.RefreshBalance(ctx, user)
 		if err != nil {
 			p.logger.Error(err.Error())
 		}
@@ -196,37 +401,185 @@ func (p *PointsService) Transfer(ctx context.Context, userfrom string, userto st
 	return nil
 }
 
-// баланс
-func (p *PointsService) GetBalance(ctx context.Context, user string) (points float64, err error) {
-	// cache
+// снятие резерва
+func (p *PointsService) ReleaseHold(ctx context.Context, redeemId string, events ...model.OutboxMessage) error {
+	user, err := p.db.ReleaseHold(ctx, redeemId, events...)
+	if err != nil {
+		return err
+	}
 	if p.cache != nil {
-		points, err = p.cache.GetBalance(ctx, user)
+		err = p.RefreshBalance(ctx, user)
 		if err != nil {
-			// database
-			points, err = p.db.GetBalance(ctx, user)
-			if err != nil {
-				return 0, err
-			}
-			_ = p.cache.SetBalance(ctx, user, points)
+			p.logger.Error(err.Error())
 		}
-	} else {
-		points, err = p.db.GetBalance(ctx, user)
+	}
+	return nil
+}
+
+// снятие просроченных резервов
+func (p *PointsService) ReleaseExpiredHolds(ctx context.Context) (count int, err error) {
+	users, err := p.db.ReleaseExpiredHolds(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, user := range users {
+		err = p.RefreshBalance(ctx, user)
 		if err != nil {
-			return 0, err
+			p.logger.Error(err.Error())
 		}
 	}
-	return
+	return len(users), nil
+}
+
+// сгорание промо-баллов с истекшим сроком действия
+func (p *PointsService) ExpirePoints(ctx context.Context) (count int, err error) {
+	users, err := p.db.ExpirePoints(ctx, model.WALLET_PROMO, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, user := range users {
+		err = p.RefreshBalance(ctx, user)
+		if err != nil {
+			p.logger.Error(err.Error())
+		}
+	}
+	return len(users), nil
 }
 
-// инвалидировать кэш баланса
-func (p *PointsService) InvalidateBalance(ctx context.Context, user string) error {
+// перевод баллов кошелька
+func (p *PointsService) Transfer(ctx context.Context, userfrom string, userto string, wallet string, points float64, transferId string) error {
+	if points <= 0 {
+		return fmt.Errorf("%w: points must be positive", model.ErrInvalidArgument)
+	}
+	if transferId == "" {
+		return fmt.Errorf("%w: transferId is required", model.ErrInvalidArgument)
+	}
+	if userfrom == userto {
+		return fmt.Errorf("%w: transfer to the same user", model.ErrInvalidArgument)
+	}
+	wallet, err := walletCode(wallet)
+	if err != nil {
+		return err
+	}
+	// срок действия промо-баллов привязан к начислению и при переводе был бы потерян
+	if wallet == model.WALLET_PROMO {
+		return fmt.Errorf("%w: %s points can not be transferred", model.ErrInvalidArgument, wallet)
+	}
+	err = p.db.Transfer(ctx, userfrom, userto, wallet, points, transferId)
+	if err != nil {
+		return err
+	}
+
 	if p.cache != nil {
-		err := p.cache.InvalidateBalance(ctx, user)
+		err = p.RefreshBalance(ctx, userfrom)
+		if err != nil {
+			p.logger.Error(err.Error())
+		}
+		err = p.RefreshBalance(ctx, userto)
+		if err != nil {
+			p.logger.Error(err.Error())
+		}
+	}
+	return nil
+}
+
+// ручная корректировка баланса кошелька, points со знаком
+func (p *PointsService) Adjust(ctx context.Context, userId string, wallet string, points float64, adjustId string, reason string) error {
+	if points == 0 {
+		return fmt.Errorf("%w: points must be non-zero", model.ErrInvalidArgument)
+	}
+	if adjustId == "" {
+		return fmt.Errorf("%w: adjustId is required", model.ErrInvalidArgument)
+	}
+	if !model.AdjustReasons[reason] {
+		return fmt.Errorf("%w: unknown reason code %s", model.ErrInvalidArgument, reason)
+	}
+	wallet, err := walletCode(wallet)
+	if err != nil {
 		return err
 	}
+	err = p.db.Adjust(ctx, userId, wallet, points, adjustId, reason)
+	if err != nil {
+		return err
+	}
+	p.logger.Info("adjust",
+		zap.String("user", userId),
+		zap.String("wallet", wallet),
+		zap.Float64("points", points),
+		zap.String("id", adjustId),
+		zap.String("reason", reason))
+
+	if p.cache != nil {
+		err = p.RefreshBalance(ctx, userId)
+		if err != nil {
+			p.logger.Error(err.Error())
+		}
+	}
 	return nil
 }
 
+// балансы кошельков и уровень статуса, кэшируются вместе
+func (p *PointsService) GetBalance(ctx context.Context, user string) (balance model.BalanceVersion, err error) {
+	if p.cache == nil {
+		return p.db.GetBalance(ctx, user)
+	}
+	// cache
+	balance, err = p.cache.GetBalance(ctx, user)
+	if err == nil {
+		if balance.NotFound {
+			return model.BalanceVersion{}, fmt.Errorf("user %w", model.ErrNotFound)
+		}
+		return balance, nil
+	}
+	// database: один запрос на пользователя, остальные ждут его результат
+	v, err, _ := p.loads.Do(user, func() (any, error) {
+		return p.loadBalance(context.WithoutCancel(ctx), user)
+	})
+	if err != nil {
+		return model.BalanceVersion{}, err
+	}
+	return v.(model.BalanceVersion), nil
+}
+
+// чтение баланса из БД и запись в кэш, неизвестный пользователь кэшируется с коротким ttl
+func (p *PointsService) loadBalance(ctx context.Context, user string) (balance model.BalanceVersion, err error) {
+	balance, err = p.db.GetBalance(ctx, user)
+	if errors.Is(err, model.ErrNotFound) {
+		_, cerr := p.cache.SetBalance(ctx, user, model.BalanceVersion{NotFound: true})
+		if cerr != nil {
+			p.logger.Error(cerr.Error())
+		}
+		return balance, err
+	}
+	if err != nil {
+		return balance, err
+	}
+	_, err = p.cache.SetBalance(ctx, user, balance)
+	if err != nil {
+		p.logger.Error(err.Error())
+	}
+	return balance, nil
+}
+
+// обновить кэш баланса после изменения: запись с версией не перезапишет более новый баланс
+// при ошибке чтения из БД запись кэша остается до истечения ttl: после удаления версии
+// устаревшее параллельное чтение могло бы записать баланс старше текущего
+func (p *PointsService) RefreshBalance(ctx context.Context, user string) error {
+	return refreshBalance(ctx, p.db, p.cache, user)
+}
+
+func refreshBalance(ctx context.Context, db interf.PointsStorage, cache interf.CacheStorage, user string) error {
+	if cache == nil {
+		return nil
+	}
+	balance, err := db.GetBalance(ctx, user)
+	if err != nil {
+		return err
+	}
+	_, err = cache.SetBalance(ctx, user, balance)
+	return err
+}
+
 // транзакции
 func (p *PointsService) GetTnx(ctx context.Context, user string, from time.Time, to time.Time) (tnxs []model.PointTransaction, err error) {
 	tnxs, err = p.db.GetTnx(ctx, user, from, to)
@@ -237,6 +590,64 @@ func (p *PointsService) GetTnx(ctx context.Context, user string, from time.Time,
 
 }
 
+// Отчет по журналу проводок
+type LedgerReport struct {
+	Date         time.Time             `json:"date"`
+	TrialBalance []model.LedgerBalance `json:"trialBalance"` // остатки системных счетов и счетов пользователей
+	Total        float64               `json:"total"`        // сумма остатков, для сбалансированного журнала = 0
+	Drifts       []model.LedgerDrift   `json:"drifts"`       // расхождения accounts.balance с журналом
+}
+
+// журнал сбалансирован и остатки пользователей совпадают с журналом
+func (r *LedgerReport) Consistent() bool {
+	return math.Round(r.Total*100) == 0 && len(r.Drifts) == 0
+}
+
+// сверка остатков с журналом проводок
+func (p *PointsService) LedgerReport(ctx context.Context) (report *LedgerReport, err error) {
+	report = &LedgerReport{Date: time.Now()}
+	report.TrialBalance, err = p.db.TrialBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range report.TrialBalance {
+		report.Total += b.Balance
+	}
+	report.Drifts, err = p.db.VerifyLedger(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// сверка балансов с транзакциями, fix - привести балансы к транзакциям проводками корректировки
+func (p *PointsService) Reconcile(ctx context.Context, fix bool) (drifts []model.BalanceDrift, fixed int, err error) {
+	drifts, err = p.db.ReconcileBalances(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !fix {
+		return drifts, 0, nil
+	}
+	date := time.Now()
+	for _, drift := range drifts {
+		corrected, err := p.db.ReconcileBalance(ctx, drift.Account, date)
+		if err != nil {
+			p.logger.Error("Reconcile account error",
+				zap.Error(err),
+				zap.String("account", drift.Account.String()))
+			continue
+		}
+		if corrected.Difference != 0 {
+			p.logger.Info("reconcile",
+				zap.String("user", corrected.UserId),
+				zap.Float64("difference", corrected.Difference))
+			fixed++
+		}
+	}
+	return drifts, fixed, nil
+}
+
 func (p *PointsService) Log(err error) {
 	p.logger.Error(err.Error())
 }
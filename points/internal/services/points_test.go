@@ -0,0 +1,341 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	metrics "github.com/glkeru/loyalty/points/observability/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRedeemMalformed(t *testing.T) {
+	serv := NewPointService(zap.NewNop(), nil, nil)
+
+	tests := []struct {
+		name string
+		msg  string
+	}{
+		{"невалидный JSON", `{"userId":`},
+		{"нет redeemId", `{"userId":"u1","points":10}`},
+		{"неизвестный тип", `{"type":"refund","userId":"u1","points":10,"redeemId":"r1"}`},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			_, err := serv.Redeem(context.Background(), ts.msg)
+			require.ErrorIs(t, err, model.ErrMalformed)
+			require.False(t, IsRetryable(err))
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"нет ошибки", nil, false},
+		{"недостаточно баллов", model.ErrNotEnoughPoints, false},
+		{"повторный ID", fmt.Errorf("redeem r1 %w", model.ErrAlreadyExists), false},
+		{"холд не активен", model.ErrHoldNotActive, false},
+		{"остановка", context.Canceled, false},
+		{"ошибка БД", errors.New("connection refused"), true},
+		{"таймаут", context.DeadlineExceeded, true},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			require.Equal(t, ts.expected, IsRetryable(ts.err))
+		})
+	}
+}
+
+func TestAccrualTnxs(t *testing.T) {
+	commit := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	expiry := 30 * 24 * time.Hour
+
+	tnxs, err := accrualTnxs(map[string]int32{
+		model.WALLET_BASE:  100,
+		model.WALLET_PROMO: 50,
+		model.WALLET_MILES: 0,
+	}, "o1", commit, expiry)
+	require.NoError(t, err)
+	require.Len(t, tnxs, 2)
+
+	byWallet := make(map[string]model.PointTransaction)
+	for _, tnx := range tnxs {
+		require.Equal(t, "o1", tnx.OrderID)
+		require.Equal(t, model.ACCRUEL, tnx.TypeTnx)
+		require.Equal(t, commit, tnx.CommitDate)
+		byWallet[tnx.Wallet] = tnx
+	}
+	// основные баллы не сгорают, промо-баллы сгорают через expiry после зачисления
+	require.Equal(t, float64(100), byWallet[model.WALLET_BASE].Points)
+	require.True(t, byWallet[model.WALLET_BASE].ExpiresAt.IsZero())
+	require.Equal(t, float64(50), byWallet[model.WALLET_PROMO].Points)
+	require.Equal(t, commit.Add(expiry), byWallet[model.WALLET_PROMO].ExpiresAt)
+
+	_, err = accrualTnxs(map[string]int32{"GOLD": 10}, "o1", commit, expiry)
+	require.ErrorIs(t, err, model.ErrInvalidArgument)
+
+	tnxs, err = accrualTnxs(nil, "o1", commit, expiry)
+	require.NoError(t, err)
+	require.Empty(t, tnxs)
+}
+
+func TestWalletCode(t *testing.T) {
+	wallet, err := walletCode("")
+	require.NoError(t, err)
+	require.Equal(t, model.WALLET_BASE, wallet)
+
+	wallet, err = walletCode(model.WALLET_MILES)
+	require.NoError(t, err)
+	require.Equal(t, model.WALLET_MILES, wallet)
+
+	_, err = walletCode("base")
+	require.ErrorIs(t, err, model.ErrInvalidArgument)
+}
+
+// хранилище с результатом задания начисления и балансами пользователей, остальные методы не используются
+type commitStorage struct {
+	interf.PointsStorage
+	result model.CommitResult
+}
+
+func (s *commitStorage) TnxCommitOnDate(ctx context.Context, date time.Time) (model.CommitResult, error) {
+	return s.result, nil
+}
+
+func (s *commitStorage) GetBalance(ctx context.Context, user string) (model.BalanceVersion, error) {
+	return model.BalanceVersion{Balances: map[string]float64{model.WALLET_BASE: 1}, Version: 2}, nil
+}
+
+// кэш, фиксирующий обновленных пользователей
+type refreshCache struct {
+	interf.CacheStorage
+	refreshed []string
+	fail      string
+}
+
+func (c *refreshCache) SetBalance(ctx context.Context, user string, balance model.BalanceVersion) (bool, error) {
+	if user == c.fail {
+		return false, errors.New("redis is unavailable")
+	}
+	c.refreshed = append(c.refreshed, user)
+	return true, nil
+}
+
+func TestCommitOnDateRefreshesCache(t *testing.T) {
+	storage := &commitStorage{result: model.CommitResult{
+		Accounts: []model.CommittedAccount{
+			{UserId: "u1", Wallet: model.WALLET_BASE, Points: 100, Balance: 150},
+			{UserId: "u1", Wallet: model.WALLET_PROMO, Points: 20, Balance: 20},
+			{UserId: "u2", Wallet: model.WALLET_BASE, Points: 10, Balance: 10},
+			{UserId: "u3", Wallet: model.WALLET_BASE, Points: 5, Balance: 5},
+		},
+		Failed: 1,
+	}}
+	cache := &refreshCache{fail: "u3"}
+	serv := NewPointService(zap.NewNop(), storage, cache)
+
+	result, err := serv.CommitOnDate(context.Background())
+	require.NoError(t, err)
+	// кэш пользователя обновляется один раз, независимо от кол-ва кошельков
+	require.Equal(t, []string{"u1", "u2"}, cache.refreshed)
+	require.Equal(t, []string{"u1", "u2", "u3"}, result.Users())
+	require.Equal(t, 1, result.Failed)
+	require.Equal(t, 1, result.CacheFailed)
+}
+
+// хранилище, недоступное для чтения балансов
+type failingStorage struct {
+	interf.PointsStorage
+}
+
+func (s failingStorage) GetBalance(ctx context.Context, user string) (model.BalanceVersion, error) {
+	return model.BalanceVersion{}, errors.New("db is unavailable")
+}
+
+func TestRefreshBalanceKeepsVersion(t *testing.T) {
+	ctx := context.Background()
+	cache := &memoryCache{balances: map[string]model.BalanceVersion{
+		"u1": {Balances: map[string]float64{model.WALLET_BASE: 10}, Version: 5},
+	}}
+
+	// при ошибке БД запись кэша не удаляется
+	err := refreshBalance(ctx, failingStorage{}, cache, "u1")
+	require.Error(t, err)
+	// устаревшее чтение не перезаписывает баланс
+	stored, err := cache.SetBalance(ctx, "u1", model.BalanceVersion{Balances: map[string]float64{model.WALLET_BASE: 7}, Version: 4})
+	require.NoError(t, err)
+	require.False(t, stored)
+	balance, err := cache.GetBalance(ctx, "u1")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), balance.Version)
+}
+
+// хранилище балансов со счетчиком запросов, ответ задерживается до закрытия release
+type balanceStorage struct {
+	interf.PointsStorage
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (s *balanceStorage) GetBalance(ctx context.Context, user string) (model.BalanceVersion, error) {
+	s.calls.Add(1)
+	<-s.release
+	if user == "unknown" {
+		return model.BalanceVersion{}, fmt.Errorf("user %w", model.ErrNotFound)
+	}
+	return model.BalanceVersion{Balances: map[string]float64{model.WALLET_BASE: 42}, Tier: "GOLD", Version: 3}, nil
+}
+
+// кэш в памяти с проверкой версии
+type memoryCache struct {
+	interf.CacheStorage
+	mu       sync.Mutex
+	balances map[string]model.BalanceVersion
+	misses   chan string // промахи кэша, если задан
+}
+
+func (c *memoryCache) GetBalance(ctx context.Context, user string) (model.BalanceVersion, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	balance, ok := c.balances[user]
+	if !ok {
+		if c.misses != nil {
+			c.misses <- user
+		}
+		return balance, fmt.Errorf("balance cache %w", model.ErrNotFound)
+	}
+	return balance, nil
+}
+
+func (c *memoryCache) SetBalance(ctx context.Context, user string, balance model.BalanceVersion) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cur, ok := c.balances[user]; ok && cur.Version >= balance.Version {
+		return false, nil
+	}
+	c.balances[user] = balance
+	return true, nil
+}
+
+func TestGetBalanceCoalescesMisses(t *testing.T) {
+	const callers = 10
+	storage := &balanceStorage{release: make(chan struct{})}
+	cache := &memoryCache{balances: make(map[string]model.BalanceVersion), misses: make(chan string, callers)}
+	serv := NewPointService(zap.NewNop(), storage, cache)
+
+	type result struct {
+		balance model.BalanceVersion
+		err     error
+	}
+	results := make(chan result, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			balance, err := serv.GetBalance(context.Background(), "u1")
+			results <- result{balance, err}
+		}()
+	}
+	// запрос в БД ждет, пока промахнутся все запросы
+	for i := 0; i < callers; i++ {
+		<-cache.misses
+	}
+	close(storage.release)
+
+	for i := 0; i < callers; i++ {
+		r := <-results
+		require.NoError(t, r.err)
+		require.Equal(t, float64(42), r.balance.Balances[model.WALLET_BASE])
+	}
+	require.Equal(t, int32(1), storage.calls.Load())
+	// следующий запрос - из кэша вместе с уровнем
+	calls := storage.calls.Load()
+	balance, err := serv.GetBalance(context.Background(), "u1")
+	require.NoError(t, err)
+	require.Equal(t, "GOLD", balance.Tier)
+	require.Equal(t, calls, storage.calls.Load())
+	require.Equal(t, int64(3), cache.balances["u1"].Version)
+}
+
+func TestGetBalanceNegativeCache(t *testing.T) {
+	storage := &balanceStorage{release: make(chan struct{})}
+	close(storage.release)
+	cache := &memoryCache{balances: make(map[string]model.BalanceVersion)}
+	serv := NewPointService(zap.NewNop(), storage, cache)
+
+	_, err := serv.GetBalance(context.Background(), "unknown")
+	require.ErrorIs(t, err, model.ErrNotFound)
+	// неизвестный пользователь кэшируется, повторный запрос в БД не идет
+	_, err = serv.GetBalance(context.Background(), "unknown")
+	require.ErrorIs(t, err, model.ErrNotFound)
+	require.Equal(t, int32(1), storage.calls.Load())
+
+	// новый счет пользователя имеет версию выше отрицательной записи
+	stored, err := cache.SetBalance(context.Background(), "unknown", model.BalanceVersion{Balances: map[string]float64{model.WALLET_BASE: 0}, Version: 1})
+	require.NoError(t, err)
+	require.True(t, stored)
+	// устаревшая запись не перезаписывает новую
+	stored, err = cache.SetBalance(context.Background(), "unknown", model.BalanceVersion{NotFound: true})
+	require.NoError(t, err)
+	require.False(t, stored)
+}
+
+func TestTransferInvalid(t *testing.T) {
+	serv := NewPointService(zap.NewNop(), nil, nil)
+
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		wallet string
+		points float64
+	}{
+		{"нулевые баллы", "u1", "u2", model.WALLET_BASE, 0},
+		{"перевод себе", "u1", "u1", model.WALLET_BASE, 10},
+		{"неизвестный кошелек", "u1", "u2", "GOLD", 10},
+		{"промо-баллы", "u1", "u2", model.WALLET_PROMO, 10},
+	}
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			err := serv.Transfer(context.Background(), ts.from, ts.to, ts.wallet, ts.points, "t1")
+			require.ErrorIs(t, err, model.ErrInvalidArgument)
+		})
+	}
+}
+
+// хранилище с одним активным резервом
+type holdStorage struct {
+	interf.PointsStorage
+}
+
+func (s holdStorage) CaptureHold(ctx context.Context, redeemId string, events ...model.OutboxMessage) (model.PointHold, string, error) {
+	if redeemId != "r1" {
+		return model.PointHold{}, "", fmt.Errorf("hold %s %w", redeemId, model.ErrNotFound)
+	}
+	return model.PointHold{RedeemID: redeemId, Wallet: model.WALLET_MILES, Points: 30}, "u1", nil
+}
+
+// подтвержденный резерв учитывается в списанных баллах
+func TestCaptureHoldMetrics(t *testing.T) {
+	serv := NewPointService(zap.NewNop(), holdStorage{}, nil)
+	redeemed := metrics.PointsRedeemed.WithLabelValues(model.WALLET_MILES)
+	before := testutil.ToFloat64(redeemed)
+
+	require.NoError(t, serv.CaptureHold(context.Background(), "r1"))
+	require.Equal(t, before+30, testutil.ToFloat64(redeemed))
+
+	require.ErrorIs(t, serv.CaptureHold(context.Background(), "r2"), model.ErrNotFound)
+	require.Equal(t, before+30, testutil.ToFloat64(redeemed))
+}
@@ -0,0 +1,87 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+)
+
+// Максимальное списание баллов кошелька для оплаты корзины
+func (p *PointsService) QuoteRedeem(ctx context.Context, user string, wallet string, items []model.BasketItem) (quote model.RedeemQuote, err error) {
+	wallet, err = walletCode(wallet)
+	if err != nil {
+		return quote, err
+	}
+	rule, err := p.db.GetRedeemRule(ctx, wallet)
+	if err != nil {
+		return quote, err
+	}
+	balance, err := p.GetBalance(ctx, user)
+	if err != nil {
+		return quote, err
+	}
+	return quoteRedeem(rule, items, balance.Balances[wallet])
+}
+
+// Расчет списания: баллами можно оплатить не больше MaxShare суммы позиций без исключенных категорий
+// и не больше доступного баланса; списывается целое кол-во баллов, не меньше MinPoints
+func quoteRedeem(rule model.RedeemRule, items []model.BasketItem, balance float64) (quote model.RedeemQuote, err error) {
+	quote = model.RedeemQuote{Wallet: rule.Wallet, Balance: balance, Rate: rule.Rate}
+	if rule.Rate <= 0 {
+		return quote, fmt.Errorf("redeem rule for wallet %s: rate must be positive", rule.Wallet)
+	}
+	for n, item := range items {
+		if item.Price < 0 || item.Quantity < 0 {
+			return quote, fmt.Errorf("%w: items[%d]: price and quantity must not be negative", model.ErrInvalidArgument, n)
+		}
+		if rule.Excluded(item.Category) {
+			continue
+		}
+		quantity := item.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		quote.Eligible += item.Price * quantity
+	}
+	quote.Eligible = math.Round(quote.Eligible*100) / 100
+
+	points := math.Floor(min(quote.Eligible*rule.MaxShare/rule.Rate, balance))
+	if points <= 0 || points < rule.MinPoints {
+		return quote, nil
+	}
+	quote.Points = points
+	quote.Amount = math.Round(points*rule.Rate*100) / 100
+	return quote, nil
+}
+
+// проверка списания по правилам кошелька: те же ограничения, что и при расчете QuoteRedeem
+func (p *PointsService) checkRedeemRule(ctx context.Context, wallet string, points float64, items []model.BasketItem) error {
+	rule, err := p.db.GetRedeemRule(ctx, wallet)
+	if err != nil {
+		return err
+	}
+	return checkRedeem(rule, items, points)
+}
+
+// кол-во баллов не меньше MinPoints и, если передана корзина, не больше допустимой для нее доли,
+// доступный баланс проверяется при списании
+func checkRedeem(rule model.RedeemRule, items []model.BasketItem, points float64) error {
+	if points < rule.MinPoints {
+		return fmt.Errorf("%w: minimum redeem for wallet %s is %v points", model.ErrInvalidArgument, rule.Wallet, rule.MinPoints)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	quote, err := quoteRedeem(rule, items, points)
+	if err != nil {
+		return err
+	}
+	// без округления до целого балла, как в QuoteRedeem: списание может быть дробным
+	limit := math.Round(quote.Eligible*rule.MaxShare/rule.Rate*100) / 100
+	if points > limit {
+		return fmt.Errorf("%w: maximum redeem for the basket in wallet %s is %v points", model.ErrInvalidArgument, rule.Wallet, limit)
+	}
+	return nil
+}
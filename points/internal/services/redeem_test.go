@@ -0,0 +1,87 @@
+package points
+
+import (
+	"testing"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	redeemRule = model.RedeemRule{
+		Wallet:     model.WALLET_BASE,
+		Rate:       0.5,
+		MaxShare:   0.3,
+		MinPoints:  50,
+		Categories: []string{"alcohol", "tobacco"},
+	}
+	redeemBasket = []model.BasketItem{
+		{Sku: "a", Category: "food", Price: 100, Quantity: 2},
+		{Sku: "b", Category: "alcohol", Price: 1000},
+		{Sku: "c", Category: "toys", Price: 50},
+	}
+)
+
+func TestQuoteRedeem(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		items   []model.BasketItem
+		balance float64
+		points  float64
+		amount  float64
+	}{
+		// 250 * 0.3 = 75 руб. = 150 баллов
+		{"ограничение долей заказа", redeemBasket, 1000, 150, 75},
+		{"ограничение балансом", redeemBasket, 120.7, 120, 60},
+		{"меньше минимального списания", redeemBasket, 49, 0, 0},
+		{"только исключенные категории", redeemBasket[1:2], 1000, 0, 0},
+		{"пустая корзина", nil, 1000, 0, 0},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			quote, err := quoteRedeem(redeemRule, ts.items, ts.balance)
+			require.NoError(t, err)
+			require.Equal(t, ts.points, quote.Points)
+			require.Equal(t, ts.amount, quote.Amount)
+			require.Equal(t, ts.balance, quote.Balance)
+		})
+	}
+
+	quote, err := quoteRedeem(redeemRule, redeemBasket, 1000)
+	require.NoError(t, err)
+	require.Equal(t, float64(250), quote.Eligible)
+
+	_, err = quoteRedeem(redeemRule, []model.BasketItem{{Price: -1}}, 1000)
+	require.ErrorIs(t, err, model.ErrInvalidArgument)
+}
+
+func TestCheckRedeem(t *testing.T) {
+	tests := []struct {
+		name   string
+		items  []model.BasketItem
+		points float64
+		ok     bool
+	}{
+		{"в пределах доли заказа", redeemBasket, 150, true},
+		{"больше доли заказа", redeemBasket, 151, false},
+		{"меньше минимального списания", redeemBasket, 49, false},
+		{"только исключенные категории", redeemBasket[1:2], 50, false},
+		{"дробное списание в пределах доли", []model.BasketItem{{Price: 251}}, 150.5, true},
+		{"дробное списание больше доли", []model.BasketItem{{Price: 251}}, 150.7, false},
+		{"без корзины", nil, 50, true},
+		{"без корзины меньше минимального", nil, 49, false},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			err := checkRedeem(redeemRule, ts.items, ts.points)
+			if ts.ok {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, model.ErrInvalidArgument)
+		})
+	}
+}
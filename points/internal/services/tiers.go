@@ -0,0 +1,107 @@
+package points
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	interf "github.com/glkeru/loyalty/points/internal/interfaces"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"go.uber.org/zap"
+)
+
+type TierService struct {
+	logger *zap.Logger
+	db     interf.PointsStorage
+	cache  interf.CacheStorage // уровень кэшируется вместе с балансом
+}
+
+func NewTierService(logger *zap.Logger, db interf.PointsStorage, cache interf.CacheStorage) (service *TierService) {
+	return &TierService{logger, db, cache}
+}
+
+// Новый уровень по активности за период, начинающийся с from.
+// Повышение - сразу, понижение - только если текущий уровень присвоен до начала периода
+func EvaluateTier(tiers []model.Tier, account model.TierActivity, from time.Time) string {
+	if len(tiers) == 0 {
+		return account.Tier
+	}
+	target := tiers[0]
+	current := -1
+	for _, t := range tiers {
+		if account.Activity >= t.Threshold && t.Rank > target.Rank {
+			target = t
+		}
+		if t.Code == account.Tier {
+			current = t.Rank
+		}
+	}
+	switch {
+	case current < 0: // уровень удален из справочника
+		return target.Code
+	case target.Rank > current:
+		return target.Code
+	case target.Rank < current && !account.TierChangedAt.After(from):
+		return target.Code
+	}
+	return account.Tier
+}
+
+// Пересчет уровней всех счетов, возвращает кол-во изменений
+func (t *TierService) EvaluateTiers(ctx context.Context) (changed int, err error) {
+	tiers, err := t.db.GetTiers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// TODO DEFAULT
+	months, err := strconv.Atoi(os.Getenv("POINTS_TIER_MONTHS"))
+	if err != nil || months <= 0 {
+		months = 12
+	}
+	basis := os.Getenv("POINTS_TIER_BASIS")
+	if basis != model.TIER_BASIS_SPENT {
+		basis = model.TIER_BASIS_EARNED
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, -months, 0)
+	accounts, err := t.db.TierActivity(ctx, from, basis)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, account := range accounts {
+		newTier := EvaluateTier(tiers, account, from)
+		if newTier == account.Tier {
+			continue
+		}
+		// событие изменения уровня для Rule Engine и CRM, записывается в outbox вместе с изменением
+		event, err := model.NewOutboxMessage(model.OUTBOX_KAFKA, model.TOPIC_TIERS, account.UserId, model.TierChangedEvent{
+			UserId:    account.UserId,
+			OldTier:   account.Tier,
+			NewTier:   newTier,
+			Activity:  account.Activity,
+			ChangedAt: now,
+		})
+		if err != nil {
+			return changed, err
+		}
+		ok, err := t.db.SetTier(ctx, account.Account, account.Tier, newTier, account.Activity, now, event)
+		if err != nil {
+			t.logger.Error("Set tier error",
+				zap.Error(err),
+				zap.String("user", account.UserId))
+			continue
+		}
+		if ok {
+			changed++
+			err = refreshBalance(ctx, t.db, t.cache, account.UserId)
+			if err != nil {
+				t.logger.Error("Refresh balance error", zap.Error(err), zap.String("user", account.UserId))
+			}
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,46 @@
+package points
+
+import (
+	"testing"
+	"time"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateTier(t *testing.T) {
+	tiers := []model.Tier{
+		{Code: "BASIC", Threshold: 0, Rank: 0},
+		{Code: "SILVER", Threshold: 10000, Rank: 1},
+		{Code: "GOLD", Threshold: 50000, Rank: 2},
+		{Code: "PLATINUM", Threshold: 150000, Rank: 3},
+	}
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	from := now.AddDate(-1, 0, 0)
+	recent := now.AddDate(0, -1, 0) // уровень присвоен внутри периода
+	old := now.AddDate(-2, 0, 0)    // уровень присвоен до начала периода
+
+	tests := []struct {
+		name     string
+		tier     string
+		changed  time.Time
+		activity float64
+		expected string
+	}{
+		{"без активности", "BASIC", old, 0, "BASIC"},
+		{"повышение на один уровень", "BASIC", recent, 10000, "SILVER"},
+		{"повышение через уровень", "SILVER", recent, 200000, "PLATINUM"},
+		{"понижение внутри периода запрещено", "GOLD", recent, 100, "GOLD"},
+		{"понижение после периода", "GOLD", old, 12000, "SILVER"},
+		{"понижение до базового", "PLATINUM", old, 0, "BASIC"},
+		{"уровень без изменений", "GOLD", old, 60000, "GOLD"},
+		{"неизвестный уровень", "DIAMOND", recent, 60000, "GOLD"},
+	}
+
+	for _, ts := range tests {
+		t.Run(ts.name, func(t *testing.T) {
+			account := model.TierActivity{Tier: ts.tier, TierChangedAt: ts.changed, Activity: ts.activity}
+			require.Equal(t, ts.expected, EvaluateTier(tiers, account, from))
+		})
+	}
+}
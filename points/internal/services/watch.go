@@ -0,0 +1,94 @@
+package points
+
+import (
+	"sync"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+)
+
+// размер буфера событий подписчика
+const watchBuffer = 16
+
+// Рассылка событий движения баллов подписчикам по ID пользователя
+type EventHub struct {
+	mu     sync.Mutex
+	users  map[string]map[*Watcher]struct{}
+	closed bool
+}
+
+// Подписка на события пользователя
+// Events закрывается при отписке, остановке рассылки или переполнении буфера (Overflow)
+type Watcher struct {
+	Events   chan model.PointsEvent
+	Overflow bool
+	user     string
+}
+
+func NewEventHub() *EventHub {
+	return &EventHub{users: make(map[string]map[*Watcher]struct{})}
+}
+
+func (h *EventHub) Subscribe(user string) *Watcher {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w := &Watcher{Events: make(chan model.PointsEvent, watchBuffer), user: user}
+	if h.closed {
+		close(w.Events)
+		return w
+	}
+	if h.users[user] == nil {
+		h.users[user] = make(map[*Watcher]struct{})
+	}
+	h.users[user][w] = struct{}{}
+	return w
+}
+
+func (h *EventHub) Unsubscribe(w *Watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(w)
+}
+
+// отправка события подписчикам пользователя без ожидания: медленный подписчик отключается
+func (h *EventHub) Publish(event model.PointsEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for w := range h.users[event.UserId] {
+		select {
+		case w.Events <- event:
+		default:
+			w.Overflow = true
+			h.remove(w)
+		}
+	}
+}
+
+// остановка рассылки, все подписки закрываются
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, watchers := range h.users {
+		for w := range watchers {
+			h.remove(w)
+		}
+	}
+}
+
+func (h *EventHub) remove(w *Watcher) {
+	watchers, ok := h.users[w.user]
+	if !ok {
+		return
+	}
+	if _, ok := watchers[w]; !ok {
+		return
+	}
+	delete(watchers, w)
+	if len(watchers) == 0 {
+		delete(h.users, w.user)
+	}
+	close(w.Events)
+}
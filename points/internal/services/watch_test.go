@@ -0,0 +1,61 @@
+package points
+
+import (
+	"testing"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHub(t *testing.T) {
+	hub := NewEventHub()
+	w1 := hub.Subscribe("u1")
+	w2 := hub.Subscribe("u1")
+	other := hub.Subscribe("u2")
+
+	// событие получают только подписчики пользователя
+	hub.Publish(model.NewPointsEvent(model.EVENT_REDEEM, "u1", model.WALLET_BASE, -10, 90, "r1"))
+	for _, w := range []*Watcher{w1, w2} {
+		event := <-w.Events
+		require.Equal(t, model.EVENT_REDEEM, event.Type)
+		require.Equal(t, float64(90), event.Balance)
+	}
+	require.Empty(t, other.Events)
+
+	// после отписки события не отправляются, канал закрыт
+	hub.Unsubscribe(w2)
+	_, ok := <-w2.Events
+	require.False(t, ok)
+	hub.Publish(model.NewPointsEvent(model.EVENT_COMMIT, "u1", model.WALLET_BASE, 10, 100, "o1"))
+	event := <-w1.Events
+	require.Equal(t, model.EVENT_COMMIT, event.Type)
+
+	// остановка закрывает все подписки
+	hub.Close()
+	_, ok = <-w1.Events
+	require.False(t, ok)
+	_, ok = <-other.Events
+	require.False(t, ok)
+	require.False(t, w1.Overflow)
+	_, ok = <-hub.Subscribe("u1").Events
+	require.False(t, ok)
+}
+
+func TestEventHubOverflow(t *testing.T) {
+	hub := NewEventHub()
+	w := hub.Subscribe("u1")
+
+	// подписчик не читает события - после заполнения буфера отключается
+	for i := 0; i <= watchBuffer; i++ {
+		hub.Publish(model.NewPointsEvent(model.EVENT_COMMIT, "u1", model.WALLET_BASE, 1, float64(i), "o1"))
+	}
+	count := 0
+	for range w.Events {
+		count++
+	}
+	require.Equal(t, watchBuffer, count)
+	require.True(t, w.Overflow)
+
+	// повторная отписка после отключения безопасна
+	hub.Unsubscribe(w)
+}
@@ -0,0 +1,166 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/glkeru/loyalty/health"
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/zap"
+)
+
+// метрики фоновых обработчиков, source - топик Kafka или очередь RabbitMQ
+
+var (
+	MessagesConsumed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "points_messages_consumed_total",
+			Help: "Кол-во полученных сообщений",
+		},
+		[]string{"source"},
+	)
+
+	MessageDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "points_message_duration_seconds",
+			Help:    "Продолжительность обработки сообщения",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"source", "result"},
+	)
+
+	MessageFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "points_message_failures_total",
+			Help: "Кол-во ошибок обработки сообщений по причине",
+		},
+		[]string{"source", "reason"},
+	)
+
+	ConsumerLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "points_consumer_lag",
+			Help: "Кол-во необработанных сообщений: отставание от конца партиции Kafka, глубина очереди RabbitMQ",
+		},
+		[]string{"source", "partition"},
+	)
+
+	PointsAccrued = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "points_accrued_total",
+			Help: "Баллы, зачисленные на баланс",
+		},
+		[]string{"wallet"},
+	)
+
+	PointsRedeemed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "points_redeemed_total",
+			Help: "Списанные баллы",
+		},
+		[]string{"wallet"},
+	)
+
+	CommitJobDuration = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "points_commit_job_duration_seconds",
+			Help: "Продолжительность последнего задания начисления",
+		},
+	)
+
+	CommitJobAccounts = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "points_commit_job_accounts",
+			Help: "Счета, обработанные последним заданием начисления",
+		},
+		[]string{"result"},
+	)
+
+	CommitJobFailed = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "points_commit_job_failed",
+			Help: "Последнее задание начисления завершилось ошибкой: 1 - да, 0 - нет",
+		},
+	)
+)
+
+// Учет обработанного сообщения: продолжительность и причина ошибки
+func ObserveMessage(source string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+		MessageFailures.WithLabelValues(source, Reason(err)).Inc()
+	}
+	MessageDuration.WithLabelValues(source, result).Observe(time.Since(start).Seconds())
+}
+
+// Причина ошибки для метрик: ошибки данных по типу, остальные - временные
+func Reason(err error) string {
+	switch {
+	case errors.Is(err, model.ErrMalformed):
+		return "malformed"
+	case errors.Is(err, model.ErrInvalidArgument):
+		return "invalid"
+	case errors.Is(err, model.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, model.ErrAlreadyExists):
+		return "duplicate"
+	case errors.Is(err, model.ErrNotEnoughPoints):
+		return "not_enough_points"
+	case errors.Is(err, model.ErrHoldNotActive):
+		return "hold_not_active"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "temporary"
+}
+
+// HTTP сервер метрик /metrics и проверок состояния /healthz, /readyz (checker = nil - без проверок)
+// на порту POINTS_METRICS_PORT, не задан - метрики и проверки не публикуются; возвращает функцию остановки
+func Serve(logger *zap.Logger, checker *health.Checker) func() {
+	port := os.Getenv("POINTS_METRICS_PORT")
+	if port == "" {
+		logger.Warn("env POINTS_METRICS_PORT is not set, metrics are disabled")
+		return func() {}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	if checker != nil {
+		checker.Register(mux)
+	}
+	srv := &http.Server{
+		Addr:              "0.0.0.0:" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("metrics server failed", zap.Error(err))
+		}
+	}()
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}
+}
+
+// Отправка метрик разового задания в Prometheus Pushgateway POINTS_METRICS_PUSHGATEWAY, не задан - не отправляются
+// задание завершается раньше, чем Prometheus успеет прочитать /metrics
+func Push(job string) error {
+	url := os.Getenv("POINTS_METRICS_PUSHGATEWAY")
+	if url == "" {
+		return nil
+	}
+	return push.New(url, job).Gatherer(prometheus.DefaultGatherer).Push()
+}
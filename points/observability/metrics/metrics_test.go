@@ -0,0 +1,28 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	model "github.com/glkeru/loyalty/points/internal/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReason(t *testing.T) {
+	require.Equal(t, "malformed", Reason(fmt.Errorf("%w: no orderId", model.ErrMalformed)))
+	require.Equal(t, "not_enough_points", Reason(fmt.Errorf("redeem: %w", model.ErrNotEnoughPoints)))
+	require.Equal(t, "timeout", Reason(fmt.Errorf("engine: %w", context.DeadlineExceeded)))
+	require.Equal(t, "temporary", Reason(fmt.Errorf("connection refused")))
+}
+
+func TestObserveMessage(t *testing.T) {
+	ObserveMessage("test", time.Now(), nil)
+	ObserveMessage("test", time.Now(), model.ErrMalformed)
+	ObserveMessage("test", time.Now(), model.ErrMalformed)
+
+	require.Equal(t, float64(2), testutil.ToFloat64(MessageFailures.WithLabelValues("test", "malformed")))
+	require.Equal(t, 2, testutil.CollectAndCount(MessageDuration.MustCurryWith(map[string]string{"source": "test"})))
+}